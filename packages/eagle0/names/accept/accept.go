@@ -0,0 +1,129 @@
+// Package accept parses HTTP Accept headers and performs proactive content
+// negotiation as described in RFC 7231 section 5.3.2.
+package accept
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a single entry of an Accept header, such as "text/*;q=0.8".
+type MediaRange struct {
+	Type    string
+	Subtype string
+	Params  map[string]string
+	Q       float64
+}
+
+// Parse splits an Accept header into media ranges ordered from most to least
+// preferred. Entries that are not of the form type/subtype are ignored, and a
+// missing or malformed q parameter is treated as 1.
+func Parse(header string) []MediaRange {
+	ranges := []MediaRange{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		slash := strings.Index(mediaType, "/")
+		if slash <= 0 || slash == len(mediaType)-1 {
+			continue
+		}
+
+		mr := MediaRange{
+			Type:    mediaType[:slash],
+			Subtype: mediaType[slash+1:],
+			Params:  map[string]string{},
+			Q:       1,
+		}
+		if mr.Type == "*" && mr.Subtype != "*" {
+			continue
+		}
+		for _, param := range fields[1:] {
+			key, value, found := strings.Cut(param, "=")
+			if !found {
+				continue
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			value = strings.Trim(strings.TrimSpace(value), "\"")
+			if key == "q" {
+				q, err := strconv.ParseFloat(value, 64)
+				if err == nil && q >= 0 && q <= 1 {
+					mr.Q = q
+				}
+				continue
+			}
+			mr.Params[key] = value
+		}
+		ranges = append(ranges, mr)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Q != ranges[j].Q {
+			return ranges[i].Q > ranges[j].Q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+func (mr MediaRange) specificity() int {
+	switch {
+	case mr.Type == "*":
+		return 0
+	case mr.Subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Matches reports whether the media range covers the given media type, which
+// must be a concrete type/subtype without parameters.
+func (mr MediaRange) Matches(mediaType string) bool {
+	t, s, found := strings.Cut(strings.ToLower(mediaType), "/")
+	if !found {
+		return false
+	}
+	if mr.Type != "*" && mr.Type != t {
+		return false
+	}
+	return mr.Subtype == "*" || mr.Subtype == s
+}
+
+// Negotiate picks the offer the client prefers most. Offers are listed in the
+// server's order of preference, which breaks ties between equally acceptable
+// types. An empty header accepts anything, so the first offer wins. The second
+// return value is false when none of the offers is acceptable.
+func Negotiate(header string, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0], true
+	}
+
+	ranges := Parse(header)
+	best := ""
+	bestQ := 0.0
+	for _, offer := range offers {
+		q := quality(ranges, offer)
+		if q > bestQ {
+			best = offer
+			bestQ = q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// quality returns the q value of the most specific range matching the offer.
+func quality(ranges []MediaRange, offer string) float64 {
+	q := 0.0
+	specificity := -1
+	for _, mr := range ranges {
+		if mr.Matches(offer) && mr.specificity() > specificity {
+			q = mr.Q
+			specificity = mr.specificity()
+		}
+	}
+	return q
+}
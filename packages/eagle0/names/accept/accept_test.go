@@ -0,0 +1,83 @@
+package accept
+
+import (
+	"testing"
+)
+
+func TestParse_ordersByQuality(t *testing.T) {
+	ranges := Parse("text/plain;q=0.5, application/json, */*;q=0.1")
+	if len(ranges) != 3 {
+		t.Fatalf("Expected 3 ranges, got %d", len(ranges))
+	}
+	expected := []string{"application/json", "text/plain", "*/*"}
+	for i, mr := range ranges {
+		if got := mr.Type + "/" + mr.Subtype; got != expected[i] {
+			t.Errorf("Expected range %d to be %s, got %s", i, expected[i], got)
+		}
+	}
+	if ranges[1].Q != 0.5 {
+		t.Errorf("Expected q of 0.5, got %v", ranges[1].Q)
+	}
+}
+
+func TestParse_specificityBreaksTies(t *testing.T) {
+	ranges := Parse("*/*, text/*, text/html")
+	if ranges[0].Subtype != "html" || ranges[1].Subtype != "*" || ranges[2].Type != "*" {
+		t.Errorf("Expected most specific range first, got %v", ranges)
+	}
+}
+
+func TestParse_skipsMalformedEntries(t *testing.T) {
+	ranges := Parse("garbage, /json, text/, */html, text/csv;q=oops;charset=utf-8")
+	if len(ranges) != 1 {
+		t.Fatalf("Expected 1 range, got %v", ranges)
+	}
+	if ranges[0].Q != 1 {
+		t.Errorf("Expected malformed q to default to 1, got %v", ranges[0].Q)
+	}
+	if ranges[0].Params["charset"] != "utf-8" {
+		t.Errorf("Expected charset param to be kept, got %v", ranges[0].Params)
+	}
+}
+
+func TestNegotiate_exactMatch(t *testing.T) {
+	result, ok := Negotiate("application/json", []string{"text/html", "application/json"})
+	if !ok || result != "application/json" {
+		t.Errorf("Expected application/json, got '%s' (%v)", result, ok)
+	}
+}
+
+func TestNegotiate_qualityWins(t *testing.T) {
+	result, ok := Negotiate("application/json, text/plain;q=0.9", []string{"text/plain", "application/json"})
+	if !ok || result != "application/json" {
+		t.Errorf("Expected application/json, got '%s' (%v)", result, ok)
+	}
+}
+
+func TestNegotiate_serverOrderBreaksTies(t *testing.T) {
+	result, ok := Negotiate("*/*", []string{"text/html", "application/json"})
+	if !ok || result != "text/html" {
+		t.Errorf("Expected text/html, got '%s' (%v)", result, ok)
+	}
+}
+
+func TestNegotiate_emptyHeaderAcceptsFirstOffer(t *testing.T) {
+	result, ok := Negotiate("", []string{"text/html", "application/json"})
+	if !ok || result != "text/html" {
+		t.Errorf("Expected text/html, got '%s' (%v)", result, ok)
+	}
+}
+
+func TestNegotiate_mostSpecificRangeDecides(t *testing.T) {
+	result, ok := Negotiate("text/*;q=0.8, text/html;q=0, */*;q=0.1", []string{"text/html", "text/csv"})
+	if !ok || result != "text/csv" {
+		t.Errorf("Expected text/csv, got '%s' (%v)", result, ok)
+	}
+}
+
+func TestNegotiate_nothingAcceptable(t *testing.T) {
+	result, ok := Negotiate("image/png", []string{"text/html", "application/json"})
+	if ok {
+		t.Errorf("Expected no acceptable offer, got '%s'", result)
+	}
+}
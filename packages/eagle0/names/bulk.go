@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"github.com/nolen777/name-generator/packages/eagle0/names/accept"
+	"github.com/nolen777/name-generator/packages/eagle0/names/namegen"
+	"strconv"
+)
+
+// maxBulkCount is the most names a bulk request may ask for. A function's
+// response is returned whole rather than streamed, and the platform limits its
+// size, so anything larger should use the nameserver command, which streams.
+const maxBulkCount = 10000
+
+// negotiateBulkRenderer returns the NDJSON renderer if the client accepts it.
+// Bulk names are only written as NDJSON.
+func negotiateBulkRenderer(acceptHeader string) (renderer, bool) {
+	if _, ok := accept.Negotiate(acceptHeader, []string{ndjsonType}); !ok {
+		return renderer{}, false
+	}
+	return renderer{ContentType: ndjsonType, Render: renderNdjson}, true
+}
+
+// bulkResults generates opts.Count names concurrently. Each name's ID is its
+// position, and the same seed gives the same names whatever the number of
+// CPUs. It returns an error only if ctx is done before every name is made.
+func bulkResults(ctx context.Context, generator *namegen.Generator, opts options) ([]namegen.Result, error) {
+	streamOpts := namegen.StreamOptions{Count: opts.Count, Seed: opts.seed(), Gender: opts.Gender}
+	results := make([]namegen.Result, 0, opts.Count)
+	for name := range generator.Stream(ctx, streamOpts) {
+		results = append(results, namegen.Result{Id: strconv.Itoa(name.Index), Name: name.Name, Err: name.Err})
+	}
+	if err := ctx.Err(); err != nil && len(results) < opts.Count {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,85 @@
+// Command lexiconvert converts word lists between the names.tsv format and the
+// lexicon formats: a JSON or YAML document, or a directory of list files.
+//
+//	lexiconvert -in names.tsv -out names.yaml
+//	lexiconvert -in names.tsv -format dir -out lists
+//
+// The input format is chosen from the input's extension, or is a directory of
+// list files. The output format is chosen with -format, or from the output's
+// extension. JSON and YAML go to standard output if -out is not given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	in := flag.String("in", "names.tsv", "word lists to convert")
+	out := flag.String("out", "", "file or directory to write")
+	format := flag.String("format", "", "output format: json, yaml or dir")
+	flag.Parse()
+
+	if err := convert(*in, *out, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "lexiconvert:", err)
+		os.Exit(1)
+	}
+}
+
+func convert(in string, out string, format string) error {
+	lex, err := lexicon.Load(in)
+	if err != nil {
+		return err
+	}
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(out)), ".")
+	}
+	switch format {
+	case "json":
+		data, err := lexicon.WriteJSON(lex)
+		if err != nil {
+			return err
+		}
+		return writeOutput(out, data)
+	case "yaml", "yml":
+		data, err := lexicon.WriteYAML(lex)
+		if err != nil {
+			return err
+		}
+		return writeOutput(out, data)
+	case "dir":
+		if out == "" {
+			return fmt.Errorf("-out is required for a directory")
+		}
+		files, err := lexicon.Files(lex)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(out, 0o755); err != nil {
+			return err
+		}
+		for name, contents := range files {
+			if err := os.WriteFile(filepath.Join(out, name), contents, 0o644); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "":
+		return fmt.Errorf("choose an output format with -format")
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeOutput(out string, data []byte) error {
+	if out == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
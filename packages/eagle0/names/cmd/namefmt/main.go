@@ -0,0 +1,88 @@
+// Command namefmt rewrites name templates in canonical form: one token per
+// place, weights written as plain decimals, and long oneof lists split one
+// entry per line.
+//
+//	namefmt nameConstruction.txt
+//	namefmt -w nameConstruction.txt
+//
+// The formatted template is checked to parse back to the same tokens before
+// it is printed or written. With no files, namefmt formats standard input.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"io"
+	"os"
+)
+
+func main() {
+	write := flag.Bool("w", false, "write the result to the file instead of standard output")
+	list := flag.Bool("l", false, "list files whose formatting differs")
+	width := flag.Int("width", 0, "line width to aim for")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write || *list {
+			fmt.Fprintln(os.Stderr, "namefmt: -w and -l need file arguments")
+			os.Exit(2)
+		}
+		if err := formatStdin(*width); err != nil {
+			fmt.Fprintln(os.Stderr, "namefmt:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		if err := formatFile(path, *width, *write, *list); err != nil {
+			fmt.Fprintf(os.Stderr, "namefmt: %s: %v\n", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func formatStdin(width int) error {
+	contents, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	formatted, err := parser.Reformat(string(contents), width)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.WriteString(formatted)
+	return err
+}
+
+func formatFile(path string, width int, write bool, list bool) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	formatted, err := parser.Reformat(string(contents), width)
+	if err != nil {
+		return err
+	}
+
+	changed := !bytes.Equal(contents, []byte(formatted))
+	if list && changed {
+		fmt.Println(path)
+	}
+	if write {
+		if !changed {
+			return nil
+		}
+		return os.WriteFile(path, []byte(formatted), 0o644)
+	}
+	if !list {
+		_, err = os.Stdout.WriteString(formatted)
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+// Command nameserver serves generated names over HTTP. GET /names/stream
+// streams names as newline-delimited JSON, one object per name, flushing as
+// each chunk of names is ready:
+//
+//	curl 'localhost:8080/names/stream?count=100000&seed=7&gender=female'
+//
+// The query may also give a template to generate from instead of the one the
+// server was started with. The same seed and template give the same names
+// however many workers the server uses.
+//
+// The names function's bulk mode gives the same kind of output, but returns
+// it whole and so is limited to smaller counts.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/namegen"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	template := flag.String("template", "nameConstruction.txt", "template to generate from")
+	words := flag.String("words", "names.tsv", "word lists, in any format lexiconvert reads")
+	maxCount := flag.Int("max", 1000000, "most names one request may ask for")
+	workers := flag.Int("workers", 0, "names generated at once per request; 0 means one per CPU")
+	flag.Parse()
+
+	generator, err := load(*template, *words)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nameserver:", err)
+		os.Exit(1)
+	}
+	s := newServer(generator, *maxCount, *workers)
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
+
+func load(templatePath string, wordsPath string) (*namegen.Generator, error) {
+	lex, err := lexicon.Load(wordsPath)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := parser.ParseFrom(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", templatePath, err)
+	}
+	generator, err := namegen.New(lex, tok)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", templatePath, err)
+	}
+	if missing := generator.MissingLists(); len(missing) > 0 {
+		return nil, fmt.Errorf("the template uses lists the word lists don't define: %s", strings.Join(missing, ", "))
+	}
+	return generator, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/namegen"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultCount = 1000
+
+type server struct {
+	generator *namegen.Generator
+	maxCount  int
+	workers   int
+	mux       *http.ServeMux
+}
+
+func newServer(generator *namegen.Generator, maxCount int, workers int) *server {
+	s := &server{generator: generator, maxCount: maxCount, workers: workers, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/names/stream", s.stream)
+	return s
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// streamedName is one line of a stream.
+type streamedName struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Gender string `json:"gender"`
+	Error  string `json:"error,omitempty"`
+}
+
+// parseStreamOptions reads the query of a stream request, and returns the
+// generator to use if the query gives its own template.
+func (s *server) parseStreamOptions(r *http.Request) (namegen.StreamOptions, *namegen.Generator, error) {
+	query := r.URL.Query()
+	opts := namegen.StreamOptions{Count: defaultCount, Workers: s.workers}
+
+	if count := query.Get("count"); count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return opts, nil, fmt.Errorf("invalid count %q", count)
+		}
+		if n > s.maxCount {
+			return opts, nil, fmt.Errorf("count %d is more than the limit of %d", n, s.maxCount)
+		}
+		opts.Count = n
+	}
+	if seed := query.Get("seed"); seed != "" {
+		n, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return opts, nil, fmt.Errorf("invalid seed %q", seed)
+		}
+		opts.Seed = n
+	}
+	switch gender := strings.TrimSpace(query.Get("gender")); gender {
+	case "", lexicon.Female, lexicon.Male, namegen.Other:
+		opts.Gender = gender
+	default:
+		return opts, nil, fmt.Errorf("invalid gender %q", gender)
+	}
+
+	template := query.Get("template")
+	if template == "" {
+		return opts, s.generator, nil
+	}
+	tok, err := parser.ParseFrom(template)
+	if err != nil {
+		return opts, nil, fmt.Errorf("invalid template: %w", err)
+	}
+	generator, err := s.generator.WithTemplate(tok)
+	if err != nil {
+		return opts, nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if missing := generator.MissingLists(); len(missing) > 0 {
+		return opts, nil, fmt.Errorf("the template uses undefined lists: %s", strings.Join(missing, ", "))
+	}
+	return opts, generator, nil
+}
+
+func (s *server) stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	opts, generator, err := s.parseStreamOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, _ := w.(http.Flusher)
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+
+	// Flush at the end of each chunk so the client sees names as they're
+	// made, without a write to the connection for every name.
+	err = generator.Each(r.Context(), opts, func(name namegen.StreamedName) error {
+		line := streamedName{ID: name.Index, Name: name.Name, Gender: name.Gender}
+		if name.Err != nil {
+			line.Error = name.Err.Error()
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+		if (name.Index+1)%namegen.ChunkSize == 0 {
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, r.Context().Err()) {
+		// The status has been sent, so all that can be done is to end the
+		// stream with the error.
+		enc.Encode(streamedName{ID: -1, Error: err.Error()})
+	}
+	out.Flush()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/namegen"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testServer(t *testing.T, workers int) *server {
+	tok, err := parser.ParseFrom(`$name " the " #noun`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lex := lexicon.New()
+	lex.Add("name", lexicon.Entry{Text: "Ada", Tags: []string{lexicon.Female}})
+	lex.Add("name", lexicon.Entry{Text: "Bob", Tags: []string{lexicon.Male}})
+	lex.Add("noun", lexicon.Entry{Text: "wolf"})
+	lex.Add("noun", lexicon.Entry{Text: "raven"})
+	generator, err := namegen.New(lex, tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newServer(generator, 5000, workers)
+}
+
+func get(t *testing.T, s *server, url string) (*httptest.ResponseRecorder, []streamedName) {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	names := []streamedName{}
+	if w.Code != http.StatusOK {
+		return w, names
+	}
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var name streamedName
+		if err := json.Unmarshal(scanner.Bytes(), &name); err != nil {
+			t.Fatalf("Expected a JSON line, got %q: %v", scanner.Text(), err)
+		}
+		names = append(names, name)
+	}
+	return w, names
+}
+
+func TestStream(t *testing.T) {
+	w, names := get(t, testServer(t, 1), "/names/stream?count=2500&seed=3")
+	if got := w.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Expected NDJSON, got %q", got)
+	}
+	if !w.Flushed {
+		t.Errorf("Expected the response to be flushed as it was written")
+	}
+	if len(names) != 2500 {
+		t.Fatalf("Expected 2500 names, got %d", len(names))
+	}
+	for i, name := range names {
+		if name.ID != i || name.Error != "" || name.Name == "" {
+			t.Fatalf("Expected name %d in order without error, got %+v", i, name)
+		}
+	}
+
+	_, other := get(t, testServer(t, 4), "/names/stream?count=2500&seed=3")
+	if diff := cmp.Diff(names, other); diff != "" {
+		t.Errorf("Expected the same names with more workers (-want +got):\n%s", diff)
+	}
+}
+
+func TestStream_options(t *testing.T) {
+	s := testServer(t, 0)
+	_, names := get(t, s, "/names/stream?count=10&gender=female&template=%24name")
+	if len(names) != 10 {
+		t.Fatalf("Expected 10 names, got %d", len(names))
+	}
+	for _, name := range names {
+		if name.Name != "Ada" || name.Gender != lexicon.Female {
+			t.Errorf("Expected a female name from the template, got %+v", name)
+		}
+	}
+
+	for _, url := range []string{
+		"/names/stream?count=-1",
+		"/names/stream?count=5001",
+		"/names/stream?seed=x",
+		"/names/stream?gender=robot",
+		"/names/stream?template=%5B",
+		"/names/stream?template=%24missing",
+	} {
+		if w, _ := get(t, s, url); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be a bad request, got %d", url, w.Code)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Command namesim generates a large sample of names from a template and
+// reports how they are distributed, to check that the template's weights do
+// what was intended.
+//
+//	namesim -n 1000000 -seed 1
+//	namesim -template draft.txt -words names.yaml -gender female -json
+//
+// The report covers name lengths, how often each oneof entry and optional was
+// chosen against its declared weight, how much of each list is used, how
+// often names repeat within batches of various sizes, and the most frequent
+// names.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/namegen"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	template := flag.String("template", "nameConstruction.txt", "template to generate from")
+	words := flag.String("words", "names.tsv", "word lists, in any format lexiconvert reads")
+	n := flag.Int("n", 100000, "number of names to generate")
+	seed := flag.Int64("seed", 1, "random seed")
+	gender := flag.String("gender", "mixed", "female, male, other, or mixed to choose like the names function")
+	batches := flag.String("batches", "10,100,1000,10000", "batch sizes to report duplicate rates for")
+	top := flag.Int("top", 20, "number of most frequent names to list")
+	asJSON := flag.Bool("json", false, "write the report as JSON")
+	flag.Parse()
+
+	batchSizes, err := parseBatchSizes(*batches)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "namesim:", err)
+		os.Exit(2)
+	}
+	if err := simulate(*template, *words, *n, *seed, *gender, batchSizes, *top, *asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, "namesim:", err)
+		os.Exit(1)
+	}
+}
+
+func parseBatchSizes(spec string) ([]int, error) {
+	sizes := []int{}
+	for _, field := range strings.Split(spec, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		size, err := strconv.Atoi(field)
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid batch size %q", field)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// chooseGender picks a gender the way the names function does when a request
+// doesn't give one.
+func chooseGender(gender string, rGen *rand.Rand) string {
+	if gender != "mixed" {
+		return gender
+	}
+	return namegen.PickGender(rGen)
+}
+
+func simulate(templatePath string, wordsPath string, n int, seed int64, gender string, batchSizes []int, top int, asJSON bool) error {
+	lex, err := lexicon.Load(wordsPath)
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(templatePath)
+	if err != nil {
+		return err
+	}
+	tok, err := parser.ParseFrom(string(contents))
+	if err != nil {
+		return fmt.Errorf("%s: %w", templatePath, err)
+	}
+	g, err := namegen.New(lex, tok)
+	if err != nil {
+		return fmt.Errorf("%s: %w", templatePath, err)
+	}
+	if missing := g.MissingLists(); len(missing) > 0 {
+		return fmt.Errorf("the template uses lists the word lists don't define: %s", strings.Join(missing, ", "))
+	}
+
+	rGen := rand.New(rand.NewSource(seed))
+	s := newStats(lex)
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		name, derivation, err := g.Explain(ctx, rGen, chooseGender(gender, rGen))
+		if err != nil {
+			s.failures++
+			continue
+		}
+		s.add(name, tok, derivation)
+	}
+
+	r := s.report(batchSizes, top)
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	r.writeText(os.Stdout, lex)
+	return nil
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// branch counts how often the entries of a oneof, or the body of an optional,
+// were chosen.
+type branch struct {
+	Path string `json:"path"`
+	// Template is the branch's token in template syntax.
+	Template string `json:"template"`
+	Visits   int    `json:"visits"`
+	// Declared is each entry's share of the total weight, or an optional's
+	// odds. Observed is the share of visits that chose it.
+	Declared []float64 `json:"declared"`
+	Counts   []int     `json:"counts"`
+}
+
+func (b *branch) observed(i int) float64 {
+	if b.Visits == 0 {
+		return 0
+	}
+	return float64(b.Counts[i]) / float64(b.Visits)
+}
+
+// listUsage counts how often each entry of a list was picked.
+type listUsage struct {
+	List    string `json:"list"`
+	Entries int    `json:"entries"`
+	Picks   int    `json:"picks"`
+	// Counts is keyed by the entry's position in the list.
+	Counts map[int]int `json:"counts"`
+}
+
+// stats accumulates what a sample of names looks like.
+type stats struct {
+	lex      *lexicon.Lexicon
+	names    []string
+	failures int
+	lengths  map[int]int
+	branches map[string]*branch
+	lists    map[string]*listUsage
+}
+
+func newStats(lex *lexicon.Lexicon) *stats {
+	return &stats{
+		lex:      lex,
+		lengths:  map[int]int{},
+		branches: map[string]*branch{},
+		lists:    map[string]*listUsage{},
+	}
+}
+
+// add records a generated name and how it was derived from tok.
+func (s *stats) add(name string, tok token.StringConstructionToken, d *token.Derivation) {
+	s.names = append(s.names, name)
+	s.lengths[utf8.RuneCountInString(name)]++
+	s.observe(tok, d, "root")
+}
+
+// observe walks tok and its derivation together, counting the choices made.
+func (s *stats) observe(tok token.StringConstructionToken, d *token.Derivation, path string) {
+	if d == nil {
+		return
+	}
+	child := func(i int) *token.Derivation {
+		if i < len(d.Children) {
+			return d.Children[i]
+		}
+		return nil
+	}
+
+	switch tok := tok.(type) {
+	case token.SequenceToken:
+		for i, t := range tok.Tokens {
+			s.observe(t, child(i), path+"."+strconv.Itoa(i))
+		}
+	case token.OptionalToken:
+		b := s.branch(path, tok, []float64{tok.Odds})
+		b.Visits++
+		if d.Fired != nil && *d.Fired {
+			b.Counts[0]++
+			s.observe(tok.Token, child(0), path+".0")
+		}
+	case token.OneofListToken:
+		total := 0.0
+		for _, entry := range tok.Entries {
+			total += entry.Weight
+		}
+		declared := make([]float64, len(tok.Entries))
+		for i, entry := range tok.Entries {
+			if total > 0 {
+				declared[i] = entry.Weight / total
+			}
+		}
+		b := s.branch(path, tok, declared)
+		b.Visits++
+		if d.Chosen != nil {
+			b.Counts[*d.Chosen]++
+			s.observe(tok.Entries[*d.Chosen].Token, child(0), path+"."+strconv.Itoa(*d.Chosen))
+		}
+	case token.TitleCaseToken:
+		s.observe(tok.Base, child(0), path+".0")
+	case token.ListSelectionToken:
+		if d.Index == nil {
+			return
+		}
+		usage, ok := s.lists[d.List]
+		if !ok {
+			usage = &listUsage{List: d.List, Counts: map[int]int{}}
+			if list, ok := s.lex.Lists[d.List]; ok {
+				usage.Entries = len(list.Entries)
+			}
+			s.lists[d.List] = usage
+		}
+		usage.Picks++
+		usage.Counts[*d.Index]++
+	}
+}
+
+func (s *stats) branch(path string, tok token.StringConstructionToken, declared []float64) *branch {
+	b, ok := s.branches[path]
+	if !ok {
+		b = &branch{Path: path, Template: abbreviate(fmt.Sprint(tok), 60), Declared: declared, Counts: make([]int, len(declared))}
+		s.branches[path] = b
+	}
+	return b
+}
+
+// pathLess orders branch paths as they appear in the template, comparing
+// their numbered steps as numbers.
+func pathLess(a string, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
+
+func abbreviate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-1]) + "…"
+}
+
+// duplicateRate returns the share of names that repeat an earlier name in the
+// same batch, when the sample is split into batches of size n. A final batch
+// smaller than n is left out.
+func duplicateRate(names []string, n int) float64 {
+	batches := len(names) / n
+	if batches == 0 {
+		return 0
+	}
+	duplicates := 0
+	for b := 0; b < batches; b++ {
+		seen := make(map[string]bool, n)
+		for _, name := range names[b*n : (b+1)*n] {
+			if seen[name] {
+				duplicates++
+			}
+			seen[name] = true
+		}
+	}
+	return float64(duplicates) / float64(batches*n)
+}
+
+type nameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// mostFrequent returns the n most generated names, most frequent first.
+func mostFrequent(names []string, n int) []nameCount {
+	counts := map[string]int{}
+	for _, name := range names {
+		counts[name]++
+	}
+	result := make([]nameCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, nameCount{name, count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+type lengthCount struct {
+	Length int `json:"length"`
+	Count  int `json:"count"`
+}
+
+type duplicates struct {
+	BatchSize int     `json:"batchSize"`
+	Rate      float64 `json:"rate"`
+}
+
+// report is the summary of a sample.
+type report struct {
+	Samples      int           `json:"samples"`
+	Failures     int           `json:"failures"`
+	Distinct     int           `json:"distinct"`
+	MeanLength   float64       `json:"meanLength"`
+	Lengths      []lengthCount `json:"lengths"`
+	Branches     []*branch     `json:"branches"`
+	Lists        []*listUsage  `json:"lists"`
+	Duplicates   []duplicates  `json:"duplicates"`
+	MostFrequent []nameCount   `json:"mostFrequent"`
+}
+
+func (s *stats) report(batchSizes []int, top int) report {
+	r := report{Samples: len(s.names) + s.failures, Failures: s.failures}
+
+	distinct := map[string]bool{}
+	totalLength := 0
+	for _, name := range s.names {
+		distinct[name] = true
+		totalLength += utf8.RuneCountInString(name)
+	}
+	r.Distinct = len(distinct)
+	if len(s.names) > 0 {
+		r.MeanLength = float64(totalLength) / float64(len(s.names))
+	}
+
+	for length, count := range s.lengths {
+		r.Lengths = append(r.Lengths, lengthCount{length, count})
+	}
+	sort.Slice(r.Lengths, func(i, j int) bool { return r.Lengths[i].Length < r.Lengths[j].Length })
+
+	for _, b := range s.branches {
+		r.Branches = append(r.Branches, b)
+	}
+	sort.Slice(r.Branches, func(i, j int) bool { return pathLess(r.Branches[i].Path, r.Branches[j].Path) })
+
+	for _, usage := range s.lists {
+		r.Lists = append(r.Lists, usage)
+	}
+	sort.Slice(r.Lists, func(i, j int) bool { return r.Lists[i].List < r.Lists[j].List })
+
+	for _, n := range batchSizes {
+		if n <= len(s.names) {
+			r.Duplicates = append(r.Duplicates, duplicates{n, duplicateRate(s.names, n)})
+		}
+	}
+	r.MostFrequent = mostFrequent(s.names, top)
+	return r
+}
+
+// writeText writes the report for reading.
+func (r report) writeText(w io.Writer, lex *lexicon.Lexicon) {
+	fmt.Fprintf(w, "%d names, %d failed, %d distinct, mean length %.1f\n", r.Samples, r.Failures, r.Distinct, r.MeanLength)
+
+	fmt.Fprintln(w, "\nLength")
+	most := 0
+	for _, l := range r.Lengths {
+		if l.Count > most {
+			most = l.Count
+		}
+	}
+	for _, l := range r.Lengths {
+		fmt.Fprintf(w, "%4d %6.2f%% %s\n", l.Length, percent(l.Count, r.Samples-r.Failures), strings.Repeat("#", (l.Count*50+most-1)/most))
+	}
+
+	fmt.Fprintln(w, "\nBranches (observed vs declared)")
+	for _, b := range r.Branches {
+		fmt.Fprintf(w, "%s %s, %d visits\n", b.Path, b.Template, b.Visits)
+		for i := range b.Counts {
+			fmt.Fprintf(w, "  %3d %6.2f%% vs %6.2f%%\n", i, 100*b.observed(i), 100*b.Declared[i])
+		}
+	}
+
+	fmt.Fprintln(w, "\nLists")
+	for _, usage := range r.Lists {
+		top, topCount := -1, 0
+		for i, count := range usage.Counts {
+			if count > topCount || (count == topCount && i < top) {
+				top, topCount = i, count
+			}
+		}
+		fmt.Fprintf(w, "%s: %d picks, %d of %d entries used", usage.List, usage.Picks, len(usage.Counts), usage.Entries)
+		if list, ok := lex.Lists[usage.List]; ok && top >= 0 && top < len(list.Entries) {
+			fmt.Fprintf(w, ", most picked %q (%.2f%%)", list.Entries[top].Text, percent(topCount, usage.Picks))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "\nDuplicates")
+	for _, d := range r.Duplicates {
+		fmt.Fprintf(w, "batches of %d: %.3f%%\n", d.BatchSize, 100*d.Rate)
+	}
+
+	fmt.Fprintln(w, "\nMost frequent")
+	for _, n := range r.MostFrequent {
+		fmt.Fprintf(w, "%7d %s\n", n.Count, n.Name)
+	}
+}
+
+func percent(count int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(count) / float64(total)
+}
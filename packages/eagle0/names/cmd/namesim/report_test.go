@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"math/rand"
+	"testing"
+)
+
+func TestStats_countsChoices(t *testing.T) {
+	tok, err := parser.ParseFrom(`$name {0.5 " the " [0.75 $adjective, 0.25 "Bold"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lex := lexicon.FromStrings(map[string][]string{
+		"name":      {"Ada", "Bob"},
+		"adjective": {"Wise"},
+	})
+	s := newStats(lex)
+	rGen := rand.New(rand.NewSource(1))
+	for i := 0; i < 4000; i++ {
+		name, derivation, err := token.Trace(context.Background(), tok, rGen, token.StringConstructionContext{Lexicon: lex})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.add(name, tok, derivation)
+	}
+	r := s.report([]int{10, 100000}, 3)
+
+	if r.Samples != 4000 || r.Distinct != 6 {
+		t.Errorf("Expected 4000 samples of 6 distinct names, got %d of %d", r.Samples, r.Distinct)
+	}
+	paths := []string{}
+	for _, b := range r.Branches {
+		paths = append(paths, b.Path)
+	}
+	if !cmp.Equal(paths, []string{"root.1", "root.1.0.1"}) {
+		t.Fatalf("Expected the optional and the oneof, got %v", paths)
+	}
+	optional, oneof := r.Branches[0], r.Branches[1]
+	if optional.Visits != 4000 || optional.observed(0) < 0.45 || optional.observed(0) > 0.55 {
+		t.Errorf("Expected the optional to fire about half the time, got %d of %d", optional.Counts[0], optional.Visits)
+	}
+	if oneof.Visits != optional.Counts[0] || !cmp.Equal(oneof.Declared, []float64{0.75, 0.25}) {
+		t.Errorf("Expected the oneof to be visited when the optional fired, got %+v", oneof)
+	}
+	if len(r.Lists) != 2 || r.Lists[1].List != "name" || r.Lists[1].Picks != 4000 || len(r.Lists[1].Counts) != 2 {
+		t.Errorf("Expected both names to be picked, got %+v", r.Lists)
+	}
+	if len(r.Duplicates) != 1 || r.Duplicates[0].BatchSize != 10 || r.Duplicates[0].Rate == 0 {
+		t.Errorf("Expected duplicates within batches of 10, got %+v", r.Duplicates)
+	}
+	if len(r.MostFrequent) != 3 {
+		t.Errorf("Expected the 3 most frequent names, got %+v", r.MostFrequent)
+	}
+}
+
+func TestDuplicateRate(t *testing.T) {
+	names := []string{"a", "a", "b", "c", "c", "c", "d"}
+	if got := duplicateRate(names, 3); got != 3.0/6.0 {
+		t.Errorf("Expected 3 duplicates in 6 names, got %v", got)
+	}
+}
+
+func TestPathLess(t *testing.T) {
+	if !pathLess("root.0.5", "root.0.13") || pathLess("root.0.13", "root.0.5") || !pathLess("root.1", "root.1.0") {
+		t.Errorf("Expected paths to be ordered by their steps")
+	}
+}
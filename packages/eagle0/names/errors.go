@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/accept"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"html"
+	"strconv"
+)
+
+type errorKind int
+
+const (
+	errorInternal errorKind = iota
+	errorBadRequest
+	errorUnknownList
+	errorUnsatisfiable
+	errorUnknownVersion
+	errorBudgetExceeded
+	errorTimeout
+)
+
+func (kind errorKind) status() int {
+	switch kind {
+	case errorBadRequest:
+		return 400
+	case errorUnknownVersion:
+		return 404
+	case errorUnknownList, errorUnsatisfiable, errorBudgetExceeded:
+		return 422
+	case errorTimeout:
+		return 503
+	default:
+		return 500
+	}
+}
+
+func (kind errorKind) slug() string {
+	switch kind {
+	case errorBadRequest:
+		return "bad-request"
+	case errorUnknownList:
+		return "unknown-list"
+	case errorUnsatisfiable:
+		return "unsatisfiable-constraint"
+	case errorUnknownVersion:
+		return "unknown-version"
+	case errorBudgetExceeded:
+		return "budget-exceeded"
+	case errorTimeout:
+		return "timeout"
+	default:
+		return "internal"
+	}
+}
+
+func (kind errorKind) title() string {
+	switch kind {
+	case errorBadRequest:
+		return "Bad request"
+	case errorUnknownList:
+		return "Unknown list"
+	case errorUnsatisfiable:
+		return "Unsatisfiable constraint"
+	case errorUnknownVersion:
+		return "Unknown version"
+	case errorBudgetExceeded:
+		return "Generation budget exceeded"
+	case errorTimeout:
+		return "Timed out"
+	default:
+		return "Internal error"
+	}
+}
+
+// requestError is an error annotated with the kind of failure, which decides
+// the status code reported to the client.
+type requestError struct {
+	Kind errorKind
+	Err  error
+}
+
+func (e *requestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.Err
+}
+
+func badRequestError(err error) *requestError {
+	return &requestError{Kind: errorBadRequest, Err: err}
+}
+
+// classify maps an error to a requestError, recognizing the errors returned by
+// tokens. Anything unrecognized is internal.
+func classify(err error) *requestError {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		return reqErr
+	case errors.Is(err, token.ErrMissingList), errors.Is(err, token.ErrMissingKey):
+		return &requestError{Kind: errorUnknownList, Err: err}
+	case errors.Is(err, token.ErrEmptyList):
+		return &requestError{Kind: errorUnsatisfiable, Err: err}
+	case errors.Is(err, errUnpinnableVersion):
+		return badRequestError(err)
+	case errors.Is(err, errUnknownVersion):
+		return &requestError{Kind: errorUnknownVersion, Err: err}
+	case errors.Is(err, token.ErrBudgetExceeded):
+		return &requestError{Kind: errorBudgetExceeded, Err: err}
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return &requestError{Kind: errorTimeout, Err: err}
+	default:
+		return &requestError{Kind: errorInternal, Err: err}
+	}
+}
+
+// problem is an RFC 7807 problem details object.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// message is a one-line summary for formats without room for the full object.
+func (p *problem) message() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+func (e *requestError) problem() *problem {
+	detail := e.Err.Error()
+	if e.Kind == errorInternal {
+		// Internal details are logged, not exposed.
+		detail = ""
+	}
+	return &problem{
+		Type:   "urn:eagle0:names:problem:" + e.Kind.slug(),
+		Title:  e.Kind.title(),
+		Status: e.Kind.status(),
+		Detail: detail,
+	}
+}
+
+func errorResponse(acceptHeader string, err error) Response {
+	reqErr := classify(err)
+	p := reqErr.problem()
+	statusCode := strconv.Itoa(p.Status)
+
+	// A client that accepts none of these gets plain text rather than HTML.
+	contentType, ok := accept.Negotiate(acceptHeader, []string{"text/html", "application/problem+json", "application/json", "text/plain"})
+	if !ok {
+		contentType = "text/plain"
+	}
+	if contentType == "application/problem+json" || contentType == "application/json" {
+		bodyObj, err := json.Marshal(p)
+		if err == nil {
+			return Response{
+				Body:       string(bodyObj),
+				StatusCode: statusCode,
+				Headers: ResponseHeaders{
+					ContentType: "application/problem+json",
+				},
+			}
+		}
+		fmt.Println("Error marshalling problem: ", err)
+	}
+	if contentType == "text/plain" {
+		return Response{
+			Body:       p.message() + "\n",
+			StatusCode: statusCode,
+			Headers: ResponseHeaders{
+				ContentType: "text/plain",
+			},
+		}
+	}
+
+	body := "<html><h1>" + html.EscapeString(p.Title) + "</h1>"
+	if p.Detail != "" {
+		body += "<p>" + html.EscapeString(p.Detail) + "</p>"
+	}
+	return Response{
+		Body:       body + "</html>",
+		StatusCode: statusCode,
+		Headers: ResponseHeaders{
+			ContentType: "text/html",
+		},
+	}
+}
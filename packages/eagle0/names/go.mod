@@ -4,8 +4,9 @@ go 1.20
 
 require (
 	github.com/aws/aws-sdk-go v1.55.7
-	github.com/google/go-cmp v0.7.0
+	github.com/google/go-cmp v0.6.0
 	golang.org/x/text v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/jmespath/go-jmespath v0.4.0 // indirect
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+)
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Name Generator</title>
+<style>
+body { font-family: Georgia, serif; max-width: 48em; margin: 2em auto; padding: 0 1em; }
+form { display: grid; grid-template-columns: max-content 1fr; gap: 0.5em 1em; margin-bottom: 2em; }
+textarea { font-family: monospace; min-height: 6em; }
+ol { padding-left: 1.5em; }
+li { margin: 0.4em 0; }
+li button { margin-left: 0.75em; font-size: 0.8em; }
+li.error { color: #a00; }
+footer { color: #666; font-size: 0.8em; }
+</style>
+</head>
+<body>
+<h1>Name Generator</h1>
+<form method="get">
+<label for="count">Count</label>
+<input id="count" name="count" type="number" min="1" max="{{.MaxCount}}" value="{{.Options.Count}}">
+<label for="gender">Gender</label>
+<select id="gender" name="gender">
+{{- range .Genders}}
+<option value="{{.Value}}"{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+</select>
+<label for="seed">Seed</label>
+<input id="seed" name="seed" type="number" value="{{.Options.Seed}}" placeholder="random">
+<label for="version">Data version</label>
+<input id="version" name="version" value="{{.Options.Version}}" placeholder="current">
+<label for="template">Template</label>
+<textarea id="template" name="template" placeholder="default template">{{.Options.Template}}</textarea>
+<span></span>
+<button type="submit">Generate</button>
+</form>
+<ol>
+{{- range .Names}}
+{{- if .Error}}
+<li class="error">{{.Error.Title}}{{with .Error.Detail}}: {{.}}{{end}}</li>
+{{- else}}
+<li><span class="name">{{.Name}}</span><button type="button" class="copy" data-name="{{.Name}}">Copy</button></li>
+{{- end}}
+{{- end}}
+</ol>
+{{- with .Version}}
+<footer>Data version {{.}}</footer>
+{{- end}}
+<script>
+document.querySelectorAll("button.copy").forEach(function (button) {
+  button.addEventListener("click", function () {
+    navigator.clipboard.writeText(button.dataset.name).then(function () {
+      button.textContent = "Copied";
+      setTimeout(function () { button.textContent = "Copy"; }, 1500);
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+type genderOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+type page struct {
+	Names    []NameResponse
+	Version  string
+	Options  options
+	Genders  []genderOption
+	MaxCount int
+}
+
+func renderHtml(out output) (string, error) {
+	genders := []genderOption{
+		{Value: "", Label: "Any"},
+		{Value: "female", Label: "Female"},
+		{Value: "male", Label: "Male"},
+		{Value: "other", Label: "Other"},
+	}
+	for i := range genders {
+		genders[i].Selected = genders[i].Value == out.Options.Gender
+	}
+
+	version := ""
+	if len(out.Names) > 0 {
+		version = out.Names[0].Version
+	}
+
+	var sb strings.Builder
+	err := pageTemplate.Execute(&sb, page{
+		Names:    out.Names,
+		Version:  version,
+		Options:  out.Options,
+		Genders:  genders,
+		MaxCount: maxCount,
+	})
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,99 @@
+package lexicon
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const listFileExt = ".txt"
+
+// ReadDir reads a directory of list files named after the TSV headers, such
+// as "name@female.txt" or "surname.txt", each holding one entry per line.
+// Blank lines are skipped. Files for the same list are merged in name order.
+func ReadDir(fsys fs.FS) (*Lexicon, error) {
+	dirEntries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	lex := New()
+	found := false
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || path.Ext(dirEntry.Name()) != listFileExt {
+			continue
+		}
+		found = true
+		contents, err := fs.ReadFile(fsys, dirEntry.Name())
+		if err != nil {
+			return nil, err
+		}
+		list, bucket := splitBucket(strings.TrimSuffix(dirEntry.Name(), listFileExt))
+		lex.Declare(list)
+		for _, line := range Lines(contents) {
+			entry := Entry{Text: line}
+			if bucket != "" {
+				entry.Tags = []string{bucket}
+			}
+			lex.Add(list, entry)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no list files found")
+	}
+	return lex, lex.Validate()
+}
+
+// Lines splits the contents of a list file into its entries, one per line
+// after any byte order mark. Entries are not quoted, and blank lines are
+// skipped.
+func Lines(contents []byte) []string {
+	lines := []string{}
+	for _, line := range strings.FieldsFunc(strings.TrimPrefix(string(contents), "\ufeff"), isLineBreak) {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Files returns the list files ReadDir would read the lexicon from, keyed by
+// file name. The format has no room for weights, forms or more than one tag,
+// so entries using them are an error.
+func Files(lex *Lexicon) (map[string][]byte, error) {
+	files := map[string]*strings.Builder{}
+	for _, name := range lex.Names() {
+		entries := lex.Lists[name].Entries
+		if len(entries) == 0 {
+			files[name+listFileExt] = &strings.Builder{}
+		}
+		for _, entry := range entries {
+			if entry.Weight != 0 && entry.Weight != 1 || len(entry.Forms) > 0 || len(entry.Tags) > 1 {
+				return nil, fmt.Errorf("list %s: entry %q has metadata a list file can't hold", name, entry.Text)
+			}
+			if strings.IndexFunc(entry.Text, isLineBreak) >= 0 {
+				return nil, fmt.Errorf("list %s: entry %q spans lines", name, entry.Text)
+			}
+			fileName := name + listFileExt
+			if len(entry.Tags) == 1 {
+				fileName = name + "@" + entry.Tags[0] + listFileExt
+			}
+			if files[fileName] == nil {
+				files[fileName] = &strings.Builder{}
+			}
+			files[fileName].WriteString(entry.Text)
+			files[fileName].WriteString("\n")
+		}
+	}
+
+	contents := make(map[string][]byte, len(files))
+	for fileName, b := range files {
+		contents[fileName] = []byte(b.String())
+	}
+	return contents, nil
+}
+
+func isLineBreak(r rune) bool {
+	return r == '\n' || r == '\r'
+}
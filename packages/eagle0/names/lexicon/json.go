@@ -0,0 +1,85 @@
+package lexicon
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ReadJSON parses a lexicon document such as
+//
+//	{"lists": {
+//	  "name": {"entries": [
+//	    "Sam",
+//	    {"text": "Ada", "weight": 2, "tags": ["female"]}
+//	  ]},
+//	  "noun": ["wolf", {"text": "mouse", "forms": {"plural": "mice"}}]
+//	}}
+//
+// An entry that is only text may be written as a string, and a list may be
+// written as its array of entries.
+func ReadJSON(data []byte) (*Lexicon, error) {
+	lex := New()
+	if err := json.Unmarshal(data, lex); err != nil {
+		return nil, err
+	}
+	if lex.Lists == nil {
+		lex.Lists = map[string]*List{}
+	}
+	return lex, lex.Validate()
+}
+
+// WriteJSON formats a lexicon as an indented JSON document, writing plain
+// entries as strings.
+func WriteJSON(lex *Lexicon) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(lex); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// entryFields has the fields of Entry without its methods.
+type entryFields Entry
+
+func (e Entry) MarshalJSON() ([]byte, error) {
+	if e.plain() {
+		return json.Marshal(e.Text)
+	}
+	return json.Marshal(entryFields(e))
+}
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*e = Entry{Text: text}
+		return nil
+	}
+	var fields entryFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*e = Entry(fields)
+	return nil
+}
+
+func (l *List) UnmarshalJSON(data []byte) error {
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err == nil {
+		l.Entries = entries
+		return nil
+	}
+	var fields struct {
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	l.Entries = fields.Entries
+	if l.Entries == nil {
+		l.Entries = []Entry{}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Package lexicon holds word lists independently of the format they were
+// stored in. A lexicon can be read from the column-oriented names.tsv, from a
+// JSON or YAML document, or from a directory with one text file per list, and
+// written back out in any of the last three.
+package lexicon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The tags that restrict an entry to one gender. The TSV and directory formats
+// call them buckets and write them after an "@" in the list name.
+const (
+	Female = "female"
+	Male   = "male"
+)
+
+// Entry is one choice in a list.
+type Entry struct {
+	Text string `json:"text" yaml:"text"`
+	// Weight is the entry's relative chance of being chosen. Zero means the
+	// default weight of 1.
+	Weight float64 `json:"weight,omitempty" yaml:"weight,omitempty"`
+	// Tags mark the entry for filtering, such as by gender.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Forms links other forms of the entry, such as a plural, by name.
+	Forms map[string]string `json:"forms,omitempty" yaml:"forms,omitempty"`
+}
+
+// HasTag reports whether the entry is tagged with tag.
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// plain reports whether the entry is nothing but its text.
+func (e Entry) plain() bool {
+	return (e.Weight == 0 || e.Weight == 1) && len(e.Tags) == 0 && len(e.Forms) == 0
+}
+
+// List is a named list of entries.
+type List struct {
+	Entries []Entry `json:"entries" yaml:"entries"`
+}
+
+// Lexicon is a set of named lists. Each list is stored once; filtered views
+// of it are built on demand by Select.
+type Lexicon struct {
+	Lists map[string]*List `json:"lists" yaml:"lists"`
+
+	// views caches the result of Select by list and filter.
+	views sync.Map
+}
+
+// New returns an empty lexicon.
+func New() *Lexicon {
+	return &Lexicon{Lists: map[string]*List{}}
+}
+
+// FromStrings returns a lexicon of untagged entries.
+func FromStrings(lists map[string][]string) *Lexicon {
+	lex := New()
+	for name, texts := range lists {
+		lex.Declare(name)
+		for _, text := range texts {
+			lex.Add(name, Entry{Text: text})
+		}
+	}
+	return lex
+}
+
+// Add appends an entry to the named list, creating the list if needed.
+func (lex *Lexicon) Add(list string, entry Entry) {
+	lex.Declare(list)
+	lex.Lists[list].Entries = append(lex.Lists[list].Entries, entry)
+	lex.clearViews()
+}
+
+// Declare creates the named list if it doesn't exist, so that a list can be
+// present without entries.
+func (lex *Lexicon) Declare(list string) {
+	if lex.Lists[list] == nil {
+		lex.Lists[list] = &List{Entries: []Entry{}}
+		lex.clearViews()
+	}
+}
+
+// Names returns the names of the lists in sorted order.
+func (lex *Lexicon) Names() []string {
+	names := make([]string, 0, len(lex.Lists))
+	for name := range lex.Lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that every entry has text and a usable weight.
+func (lex *Lexicon) Validate() error {
+	for _, name := range lex.Names() {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, "@\t\r\n") {
+			return fmt.Errorf("invalid list name %q", name)
+		}
+		for i, entry := range lex.Lists[name].Entries {
+			if entry.Text == "" {
+				return fmt.Errorf("list %s: entry %d has no text", name, i+1)
+			}
+			if entry.Weight < 0 {
+				return fmt.Errorf("list %s: entry %q has negative weight %v", name, entry.Text, entry.Weight)
+			}
+		}
+	}
+	return nil
+}
+
+// splitBucket splits a TSV header or list file name into the list name and
+// the bucket after the "@", if any.
+func splitBucket(name string) (string, string) {
+	list, bucket, _ := strings.Cut(name, "@")
+	return list, bucket
+}
+
+// Load reads a lexicon from a path, choosing the format from its extension: a
+// directory of list files, .json, .yaml or .yml, or .tsv.
+func Load(path string) (*Lexicon, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadDir(os.DirFS(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ReadJSON(data)
+	case ".yaml", ".yml":
+		return ReadYAML(data)
+	case ".tsv":
+		return ReadTSV(data)
+	default:
+		return nil, fmt.Errorf("unknown lexicon format for %s", path)
+	}
+}
@@ -0,0 +1,220 @@
+package lexicon
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var ignoreViews = cmpopts.IgnoreUnexported(Lexicon{})
+
+const documentJSON = `{"lists": {
+  "name": {"entries": [
+    "Sam",
+    {"text": "Ada", "weight": 2, "tags": ["female"]}
+  ]},
+  "noun": ["wolf", {"text": "mouse", "forms": {"plural": "mice"}}],
+  "empty": []
+}}`
+
+const documentYAML = `lists:
+  name:
+    entries:
+      - Sam
+      - text: Ada
+        weight: 2
+        tags: [female]
+  noun:
+    - wolf
+    - text: mouse
+      forms: {plural: mice}
+  empty: []
+`
+
+func documentLexicon() *Lexicon {
+	lex := New()
+	lex.Add("name", Entry{Text: "Sam"})
+	lex.Add("name", Entry{Text: "Ada", Weight: 2, Tags: []string{Female}})
+	lex.Add("noun", Entry{Text: "wolf"})
+	lex.Add("noun", Entry{Text: "mouse", Forms: map[string]string{"plural": "mice"}})
+	lex.Declare("empty")
+	return lex
+}
+
+func TestReadJSON(t *testing.T) {
+	lex, err := ReadJSON([]byte(documentJSON))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if diff := cmp.Diff(documentLexicon(), lex, ignoreViews); diff != "" {
+		t.Errorf("Unexpected lexicon (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadYAML(t *testing.T) {
+	lex, err := ReadYAML([]byte(documentYAML))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if diff := cmp.Diff(documentLexicon(), lex, ignoreViews); diff != "" {
+		t.Errorf("Unexpected lexicon (-want +got):\n%s", diff)
+	}
+}
+
+func TestWrite_roundTrips(t *testing.T) {
+	data, err := WriteJSON(documentLexicon())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Sam"`) || strings.Contains(string(data), `"text": "Sam"`) {
+		t.Errorf("Expected plain entries to be written as strings, got %s", data)
+	}
+	fromJSON, err := ReadJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = WriteYAML(documentLexicon())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := ReadYAML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for format, lex := range map[string]*Lexicon{"JSON": fromJSON, "YAML": fromYAML} {
+		if diff := cmp.Diff(documentLexicon(), lex, ignoreViews); diff != "" {
+			t.Errorf("Expected %s to round-trip (-want +got):\n%s", format, diff)
+		}
+	}
+}
+
+func TestRead_rejectsInvalidEntries(t *testing.T) {
+	for _, document := range []string{
+		`{"lists": {"name": [""]}}`,
+		`{"lists": {"name": [{"text": "Ada", "weight": -1}]}}`,
+		`{"lists": {"name@female": ["Ada"]}}`,
+		`{"lists": {"name": [1]}}`,
+	} {
+		if _, err := ReadJSON([]byte(document)); err == nil {
+			t.Errorf("Expected an error for %s", document)
+		}
+	}
+}
+
+func TestReadTSV(t *testing.T) {
+	lex, err := ReadTSV([]byte("name@female\tname@male\tsurname\r\nAda\tBob\tJones\r\nAgnes\r\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := New()
+	expected.Add("name", Entry{Text: "Ada", Tags: []string{Female}})
+	expected.Add("name", Entry{Text: "Bob", Tags: []string{Male}})
+	expected.Add("surname", Entry{Text: "Jones"})
+	expected.Add("name", Entry{Text: "Agnes", Tags: []string{Female}})
+	if diff := cmp.Diff(expected, lex, ignoreViews); diff != "" {
+		t.Errorf("Unexpected lexicon (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadTSV([]byte("name\r\nAda\tBob")); err == nil {
+		t.Errorf("Expected an error for a row wider than the header")
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"name@female.txt": {Data: []byte("Ada\r\nAgnes\r\n\r\n")},
+		"name@male.txt":   {Data: []byte("Bob")},
+		"surname.txt":     {Data: []byte("Jones\n")},
+		"README.md":       {Data: []byte("not a list")},
+	}
+	lex, err := ReadDir(fsys)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := New()
+	expected.Add("name", Entry{Text: "Ada", Tags: []string{Female}})
+	expected.Add("name", Entry{Text: "Agnes", Tags: []string{Female}})
+	expected.Add("name", Entry{Text: "Bob", Tags: []string{Male}})
+	expected.Add("surname", Entry{Text: "Jones"})
+	if diff := cmp.Diff(expected, lex, ignoreViews); diff != "" {
+		t.Errorf("Unexpected lexicon (-want +got):\n%s", diff)
+	}
+}
+
+func TestFiles_rejectsMetadata(t *testing.T) {
+	if _, err := Files(documentLexicon()); err == nil {
+		t.Errorf("Expected an error for weights and forms")
+	}
+}
+
+// sortedLists returns each list's entries sorted by text, for comparing
+// formats that group entries differently.
+func sortedLists(lex *Lexicon) map[string][]Entry {
+	lists := map[string][]Entry{}
+	for name, list := range lex.Lists {
+		entries := append([]Entry{}, list.Entries...)
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Text < entries[j].Text })
+		lists[name] = entries
+	}
+	return lists
+}
+
+func TestConvert_namesTsv(t *testing.T) {
+	data, err := os.ReadFile("../names.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lex, err := ReadTSV(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(lex.Lists["name"].Entries) == 0 {
+		t.Fatalf("Expected names, got none")
+	}
+
+	jsonData, err := WriteJSON(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ReadJSON(jsonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(lex, fromJSON, ignoreViews); diff != "" {
+		t.Errorf("Expected JSON to round-trip (-want +got):\n%s", diff)
+	}
+
+	yamlData, err := WriteYAML(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := ReadYAML(yamlData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(lex, fromYAML, ignoreViews); diff != "" {
+		t.Errorf("Expected YAML to round-trip (-want +got):\n%s", diff)
+	}
+
+	files, err := Files(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{}
+	for name, contents := range files {
+		fsys[name] = &fstest.MapFile{Data: contents}
+	}
+	fromDir, err := ReadDir(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(sortedLists(lex), sortedLists(fromDir)); diff != "" {
+		t.Errorf("Expected list files to hold the same entries (-want +got):\n%s", diff)
+	}
+}
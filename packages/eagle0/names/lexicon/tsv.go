@@ -0,0 +1,42 @@
+package lexicon
+
+import (
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/tsv"
+)
+
+// ReadTSV converts a names.tsv, whose header row names a list in each column,
+// optionally followed by "@" and a bucket that becomes a tag on the column's
+// entries. Columns with the same list name are merged into one list, keeping
+// the order the entries appear in row by row.
+func ReadTSV(data []byte) (*Lexicon, error) {
+	records := tsv.Read(data)
+	if len(records) == 0 {
+		return nil, fmt.Errorf("word lists are empty")
+	}
+
+	headers := records[0]
+	lists := make([]string, len(headers))
+	buckets := make([]string, len(headers))
+	lex := New()
+	for i, header := range headers {
+		lists[i], buckets[i] = splitBucket(header)
+		lex.Declare(lists[i])
+	}
+	for r, values := range records[1:] {
+		if len(values) > len(headers) {
+			return nil, fmt.Errorf("row %d has %d fields but there are only %d columns", r+2, len(values), len(headers))
+		}
+		for i, value := range values {
+			if value == "" {
+				continue
+			}
+			entry := Entry{Text: value}
+			if buckets[i] != "" {
+				entry.Tags = []string{buckets[i]}
+			}
+			lex.Add(lists[i], entry)
+		}
+	}
+	return lex, nil
+}
@@ -0,0 +1,208 @@
+package lexicon
+
+import (
+	"sort"
+	"strings"
+)
+
+// CulturePrefix starts the tags that give an entry's culture, such as
+// "culture:norse".
+const CulturePrefix = "culture:"
+
+// Filter selects the entries of a list. The zero Filter selects every entry.
+type Filter struct {
+	// Gender leaves out entries tagged with the other gender. Entries without
+	// a gender tag suit any gender.
+	Gender string
+	// Culture leaves out entries tagged with a different culture. Entries
+	// without a culture tag suit any culture.
+	Culture string
+	// Tags leaves out entries that lack any of these tags.
+	Tags []string
+}
+
+// Match reports whether the filter selects an entry.
+func (f Filter) Match(e Entry) bool {
+	switch f.Gender {
+	case Female:
+		if e.HasTag(Male) && !e.HasTag(Female) {
+			return false
+		}
+	case Male:
+		if e.HasTag(Female) && !e.HasTag(Male) {
+			return false
+		}
+	}
+	if f.Culture != "" {
+		cultured := false
+		for _, tag := range e.Tags {
+			if strings.HasPrefix(tag, CulturePrefix) {
+				cultured = true
+				if tag == CulturePrefix+f.Culture {
+					cultured = false
+					break
+				}
+			}
+		}
+		if cultured {
+			return false
+		}
+	}
+	for _, tag := range f.Tags {
+		if !e.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) all() bool {
+	return (f.Gender != Female && f.Gender != Male) && f.Culture == "" && len(f.Tags) == 0
+}
+
+type viewKey struct {
+	list    string
+	gender  string
+	culture string
+	tags    string
+}
+
+func (f Filter) key(list string) viewKey {
+	key := viewKey{list: list, gender: f.Gender, culture: f.Culture}
+	if len(f.Tags) > 0 {
+		tags := append([]string{}, f.Tags...)
+		sort.Strings(tags)
+		key.tags = strings.Join(tags, "\x00")
+	}
+	return key
+}
+
+// RandomSource is the source of randomness for picking entries.
+type RandomSource interface {
+	Float64() float64
+	Intn(n int) int
+}
+
+// View is the entries of a list that a filter selects. It refers to the
+// list's entries rather than copying them.
+type View struct {
+	list *List
+	// indices are the positions of the selected entries, or nil if every
+	// entry is selected.
+	indices []int
+	// cumulative holds the running total of the selected entries' weights,
+	// or is nil if they all have the same weight.
+	cumulative []float64
+}
+
+func newView(list *List, indices []int) View {
+	v := View{list: list, indices: indices}
+	uniform := true
+	total := 0.0
+	cumulative := make([]float64, v.Len())
+	for i := range cumulative {
+		weight := v.At(i).weight()
+		if i > 0 && weight != v.At(0).weight() {
+			uniform = false
+		}
+		total += weight
+		cumulative[i] = total
+	}
+	if !uniform {
+		v.cumulative = cumulative
+	}
+	return v
+}
+
+func (e Entry) weight() float64 {
+	if e.Weight == 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+// Len returns the number of selected entries.
+func (v View) Len() int {
+	if v.indices == nil {
+		if v.list == nil {
+			return 0
+		}
+		return len(v.list.Entries)
+	}
+	return len(v.indices)
+}
+
+// At returns the i'th selected entry.
+func (v View) At(i int) Entry {
+	if v.indices == nil {
+		return v.list.Entries[i]
+	}
+	return v.list.Entries[v.indices[i]]
+}
+
+// Pick chooses an entry at random, in proportion to the entries' weights. The
+// view must not be empty.
+func (v View) Pick(rand RandomSource) Entry {
+	return v.At(v.PickIndex(rand))
+}
+
+// PickIndex is like Pick but returns the position of the chosen entry in the
+// view.
+func (v View) PickIndex(rand RandomSource) int {
+	if v.cumulative == nil {
+		return rand.Intn(v.Len())
+	}
+	target := rand.Float64() * v.cumulative[len(v.cumulative)-1]
+	i := sort.Search(len(v.cumulative), func(i int) bool { return v.cumulative[i] > target })
+	if i == len(v.cumulative) {
+		i--
+	}
+	return i
+}
+
+// ListIndex returns the position in the whole list of the view's i'th entry.
+func (v View) ListIndex(i int) int {
+	if v.indices == nil {
+		return i
+	}
+	return v.indices[i]
+}
+
+// Select returns the entries of the named list that the filter selects, and
+// whether the list exists. Views are computed the first time they are asked
+// for and cached, so a lexicon must not be changed once it is in use.
+func (lex *Lexicon) Select(list string, f Filter) (View, bool) {
+	if lex == nil {
+		return View{}, false
+	}
+	l, ok := lex.Lists[list]
+	if !ok {
+		return View{}, false
+	}
+
+	key := f.key(list)
+	if cached, ok := lex.views.Load(key); ok {
+		return cached.(View), true
+	}
+
+	var indices []int
+	if !f.all() {
+		indices = []int{}
+		for i, entry := range l.Entries {
+			if f.Match(entry) {
+				indices = append(indices, i)
+			}
+		}
+	}
+	v := newView(l, indices)
+	lex.views.Store(key, v)
+	return v, true
+}
+
+// clearViews drops the cached views after the lexicon changes.
+func (lex *Lexicon) clearViews() {
+	lex.views.Range(func(key, _ any) bool {
+		lex.views.Delete(key)
+		return true
+	})
+}
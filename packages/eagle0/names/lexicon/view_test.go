@@ -0,0 +1,122 @@
+package lexicon
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+type fixedRandomSource struct {
+	IntNValue    int
+	Float64Value float64
+}
+
+func (s fixedRandomSource) Intn(n int) int {
+	return s.IntNValue
+}
+func (s fixedRandomSource) Float64() float64 {
+	return s.Float64Value
+}
+
+func viewTexts(v View) []string {
+	texts := []string{}
+	for i := 0; i < v.Len(); i++ {
+		texts = append(texts, v.At(i).Text)
+	}
+	return texts
+}
+
+func taggedLexicon() *Lexicon {
+	lex := New()
+	lex.Add("name", Entry{Text: "Ada", Tags: []string{Female, "culture:english"}})
+	lex.Add("name", Entry{Text: "Bjorn", Tags: []string{Male, "culture:norse"}})
+	lex.Add("name", Entry{Text: "Sam"})
+	lex.Add("name", Entry{Text: "Freya", Tags: []string{Female, "culture:norse", "noble"}})
+	lex.Add("name", Entry{Text: "Alex", Tags: []string{Female, Male}})
+	return lex
+}
+
+func TestSelect_filters(t *testing.T) {
+	tests := map[string]struct {
+		filter   Filter
+		expected []string
+	}{
+		"all":             {Filter{}, []string{"Ada", "Bjorn", "Sam", "Freya", "Alex"}},
+		"unknown gender":  {Filter{Gender: "other"}, []string{"Ada", "Bjorn", "Sam", "Freya", "Alex"}},
+		"female":          {Filter{Gender: Female}, []string{"Ada", "Sam", "Freya", "Alex"}},
+		"male":            {Filter{Gender: Male}, []string{"Bjorn", "Sam", "Alex"}},
+		"culture":         {Filter{Culture: "norse"}, []string{"Bjorn", "Sam", "Freya", "Alex"}},
+		"tags":            {Filter{Tags: []string{"noble"}}, []string{"Freya"}},
+		"female norse":    {Filter{Gender: Female, Culture: "norse"}, []string{"Sam", "Freya", "Alex"}},
+		"nothing matches": {Filter{Tags: []string{"royal"}}, []string{}},
+	}
+	lex := taggedLexicon()
+	for name, test := range tests {
+		view, ok := lex.Select("name", test.filter)
+		if !ok {
+			t.Fatalf("%s: expected the list to exist", name)
+		}
+		if got := viewTexts(view); !cmp.Equal(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, got)
+		}
+	}
+}
+
+func TestSelect_missingList(t *testing.T) {
+	if _, ok := taggedLexicon().Select("surname", Filter{}); ok {
+		t.Errorf("Expected a missing list")
+	}
+	var lex *Lexicon
+	if _, ok := lex.Select("name", Filter{}); ok {
+		t.Errorf("Expected a nil lexicon to have no lists")
+	}
+}
+
+func TestSelect_cachesViewsUntilChanged(t *testing.T) {
+	lex := taggedLexicon()
+	first, _ := lex.Select("name", Filter{Gender: Female, Tags: []string{"b", "a"}})
+	second, _ := lex.Select("name", Filter{Gender: Female, Tags: []string{"a", "b"}})
+	if first.Len() != 0 || second.Len() != 0 {
+		t.Fatalf("Expected empty views")
+	}
+	if _, ok := lex.views.Load(Filter{Gender: Female, Tags: []string{"a", "b"}}.key("name")); !ok {
+		t.Errorf("Expected the view to be cached")
+	}
+
+	lex.Add("name", Entry{Text: "Zed", Tags: []string{"a", "b"}})
+	view, _ := lex.Select("name", Filter{Gender: Female, Tags: []string{"a", "b"}})
+	if !cmp.Equal(viewTexts(view), []string{"Zed"}) {
+		t.Errorf("Expected adding an entry to refresh the view, got %v", viewTexts(view))
+	}
+}
+
+func TestView_pickUsesWeights(t *testing.T) {
+	lex := New()
+	lex.Add("name", Entry{Text: "Ada", Weight: 1})
+	lex.Add("name", Entry{Text: "Bob", Weight: 3})
+	view, _ := lex.Select("name", Filter{})
+
+	for value, expected := range map[float64]string{0: "Ada", 0.24: "Ada", 0.26: "Bob", 0.99: "Bob"} {
+		if got := view.Pick(fixedRandomSource{Float64Value: value}).Text; got != expected {
+			t.Errorf("Expected %s for %v, got %s", expected, value, got)
+		}
+	}
+}
+
+func TestView_pickUniform(t *testing.T) {
+	lex := FromStrings(map[string][]string{"name": {"Ada", "Bob", "Cy"}})
+	view, _ := lex.Select("name", Filter{})
+	if got := view.Pick(fixedRandomSource{IntNValue: 2}).Text; got != "Cy" {
+		t.Errorf("Expected Cy, got %s", got)
+	}
+}
+
+func TestView_listIndex(t *testing.T) {
+	view, _ := taggedLexicon().Select("name", Filter{Gender: Male})
+	if got := view.ListIndex(1); got != 2 {
+		t.Errorf("Expected Sam to be entry 2 of the list, got %d", got)
+	}
+	all, _ := taggedLexicon().Select("name", Filter{})
+	if got := all.ListIndex(3); got != 3 {
+		t.Errorf("Expected an unfiltered view to use list positions, got %d", got)
+	}
+}
@@ -0,0 +1,83 @@
+package lexicon
+
+import (
+	"bytes"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadYAML parses a lexicon document with the same structure as ReadJSON:
+//
+//	lists:
+//	  name:
+//	    entries:
+//	      - Sam
+//	      - text: Ada
+//	        weight: 2
+//	        tags: [female]
+//	  noun:
+//	    - wolf
+//	    - text: mouse
+//	      forms: {plural: mice}
+func ReadYAML(data []byte) (*Lexicon, error) {
+	lex := New()
+	if err := yaml.Unmarshal(data, lex); err != nil {
+		return nil, err
+	}
+	if lex.Lists == nil {
+		lex.Lists = map[string]*List{}
+	}
+	return lex, lex.Validate()
+}
+
+// WriteYAML formats a lexicon as a YAML document, writing plain entries as
+// strings.
+func WriteYAML(lex *Lexicon) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(lex); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e Entry) MarshalYAML() (interface{}, error) {
+	if e.plain() {
+		return e.Text, nil
+	}
+	return entryFields(e), nil
+}
+
+func (e *Entry) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*e = Entry{Text: value.Value}
+		return nil
+	}
+	var fields entryFields
+	if err := value.Decode(&fields); err != nil {
+		return err
+	}
+	*e = Entry(fields)
+	return nil
+}
+
+func (l *List) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		l.Entries = []Entry{}
+		return value.Decode(&l.Entries)
+	}
+	var fields struct {
+		Entries []Entry `yaml:"entries"`
+	}
+	if err := value.Decode(&fields); err != nil {
+		return err
+	}
+	l.Entries = fields.Entries
+	if l.Entries == nil {
+		l.Entries = []Entry{}
+	}
+	return nil
+}
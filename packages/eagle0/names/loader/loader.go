@@ -0,0 +1,54 @@
+// Package loader turns a published word list and template into the lexicon
+// and token that names are generated from. The names function and the word
+// list updater share it, so anything the updater publishes is known to load.
+package loader
+
+import (
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+)
+
+// Data is a loaded word list and template pair.
+type Data struct {
+	Lexicon *lexicon.Lexicon
+	Token   token.StringConstructionToken
+}
+
+// Load parses a names.tsv and a nameConstruction.txt.
+func Load(namesTsv []byte, template []byte) (Data, error) {
+	lex, err := lexicon.ReadTSV(namesTsv)
+	if err != nil {
+		return Data{}, err
+	}
+	tok, err := parser.ParseFrom(string(template))
+	if err != nil {
+		return Data{}, fmt.Errorf("error parsing string construction token: %w", err)
+	}
+	return Data{Lexicon: lex, Token: tok}, nil
+}
+
+// For returns the context for generating a name of the given gender. Filtered
+// selections leave out entries tagged with the other gender; any gender but
+// "female" and "male" sees every entry.
+func (d Data) For(gender string) token.StringConstructionContext {
+	return d.With(lexicon.Filter{Gender: gender})
+}
+
+// With returns the context whose filtered selections use filter.
+func (d Data) With(filter lexicon.Filter) token.StringConstructionContext {
+	return token.StringConstructionContext{Lexicon: d.Lexicon, Filter: filter}
+}
+
+// MissingLists returns the lists the template selects from that the word
+// lists do not define.
+func (d Data) MissingLists() []string {
+	missing := []string{}
+	for _, name := range token.ListNames(d.Token) {
+		if _, ok := d.Lexicon.Lists[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,100 @@
+package loader
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// texts returns the sorted entries of a list that a context's filtered
+// selections choose from.
+func texts(t *testing.T, data Data, filter lexicon.Filter, list string) []string {
+	view, ok := data.Lexicon.Select(list, data.With(filter).Filter)
+	if !ok {
+		t.Fatalf("Expected list %s", list)
+	}
+	result := []string{}
+	for i := 0; i < view.Len(); i++ {
+		result = append(result, view.At(i).Text)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestLoad_buckets(t *testing.T) {
+	data, err := Load([]byte("name@female\tname@male\tname\r\nAda\tBob\tSam\r\nAgnes"), []byte("$name"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string][]string{
+		"female": {"Ada", "Agnes", "Sam"},
+		"male":   {"Bob", "Sam"},
+		"other":  {"Ada", "Agnes", "Bob", "Sam"},
+	}
+	for gender, names := range expected {
+		if got := texts(t, data, lexicon.Filter{Gender: gender}, "name"); !cmp.Equal(got, names) {
+			t.Errorf("Expected %s names %v, got %v", gender, names, got)
+		}
+	}
+	if ctx := data.For("female"); ctx.Filter.Gender != "female" || ctx.Lexicon != data.Lexicon {
+		t.Errorf("Expected a female context sharing the lexicon, got %+v", ctx)
+	}
+}
+
+func TestLoad_rejectsWideRows(t *testing.T) {
+	_, err := Load([]byte("name\r\nAda\tBob"), []byte("$name"))
+	if err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Errorf("Expected an error for row 2, got %v", err)
+	}
+}
+
+func TestLoad_lineEndings(t *testing.T) {
+	for _, namesTsv := range []string{"name\tsurname\nAda\tJones\n", "\ufeffname\tsurname\r\nAda\tJones\t\r\n"} {
+		data, err := Load([]byte(namesTsv), []byte("$name"))
+		if err != nil {
+			t.Fatalf("Expected no error for %q, got %v", namesTsv, err)
+		}
+		if got := texts(t, data, lexicon.Filter{}, "surname"); !cmp.Equal(got, []string{"Jones"}) {
+			t.Errorf("Expected surname [Jones] for %q, got %v", namesTsv, got)
+		}
+	}
+}
+
+func TestLoad_missingLists(t *testing.T) {
+	data, err := Load([]byte("name\r\nAda"), []byte(`$name " " $surname`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if missing := data.MissingLists(); !cmp.Equal(missing, []string{"surname"}) {
+		t.Errorf("Expected [surname] to be missing, got %v", missing)
+	}
+}
+
+func TestLoad_badTemplate(t *testing.T) {
+	if _, err := Load([]byte("name\r\nAda"), []byte("[0.5 $name")); err == nil {
+		t.Errorf("Expected an error for a bad template")
+	}
+}
+
+func TestLoad_checkedInData(t *testing.T) {
+	namesTsv, err := os.ReadFile("../names.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, err := os.ReadFile("../nameConstruction.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Load(namesTsv, template)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if missing := data.MissingLists(); len(missing) != 0 {
+		t.Errorf("Expected no missing lists, got %v", missing)
+	}
+}
@@ -0,0 +1,204 @@
+// Package namegen generates names from word lists and a template. It holds
+// everything the names function does between loading its data and writing a
+// response, so that the function, the command-line tools and other Go
+// programs generate names the same way.
+//
+// A Generator is built from data the caller has already read; this package
+// does no I/O and keeps no state outside the generators it returns.
+//
+// Each and Stream generate large numbers of names concurrently. Names are
+// made in fixed-size chunks, each with its own random stream derived from one
+// seed, so the same seed gives the same names in the same order however many
+// workers share the work.
+//
+//	lex, err := lexicon.Load("names.yaml")
+//	...
+//	tok, err := parser.ParseFrom(template)
+//	...
+//	g, err := namegen.New(lex, tok)
+//	...
+//	rGen := rand.New(rand.NewSource(1))
+//	results := g.Generate(ctx, rGen, namegen.Requests(nil, 20, "", rGen), false)
+package namegen
+
+import (
+	"context"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/loader"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"strconv"
+)
+
+// Other is the gender of a name that may use entries tagged with either
+// gender.
+const Other = "other"
+
+// DefaultBudget bounds the work for each name, so that a custom template
+// can't tie up a server. New generators use it.
+var DefaultBudget = token.Budget{
+	MaxSteps:  10000,
+	MaxDepth:  100,
+	MaxOutput: 4096,
+}
+
+// Generator generates names from a lexicon and a template. It is safe for
+// concurrent use.
+type Generator struct {
+	data    loader.Data
+	budget  token.Budget
+	program *token.Program
+}
+
+// New returns a generator for the template tok, selecting words from lex.
+func New(lex *lexicon.Lexicon, tok token.StringConstructionToken) (*Generator, error) {
+	if lex == nil {
+		return nil, fmt.Errorf("no lexicon")
+	}
+	program, err := token.Compile(tok)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{
+		data:    loader.Data{Lexicon: lex, Token: tok},
+		budget:  DefaultBudget,
+		program: program,
+	}, nil
+}
+
+// Load returns a generator for a published names.tsv and nameConstruction.txt.
+func Load(namesTsv []byte, template []byte) (*Generator, error) {
+	data, err := loader.Load(namesTsv, template)
+	if err != nil {
+		return nil, err
+	}
+	return New(data.Lexicon, data.Token)
+}
+
+// WithTemplate returns a generator that uses the same lexicon and budget as g
+// with a different template.
+func (g *Generator) WithTemplate(tok token.StringConstructionToken) (*Generator, error) {
+	other, err := New(g.data.Lexicon, tok)
+	if err != nil {
+		return nil, err
+	}
+	other.budget = g.budget
+	return other, nil
+}
+
+// WithBudget returns a copy of g that limits the work for each name to
+// budget. The zero Budget sets no limits.
+func (g *Generator) WithBudget(budget token.Budget) *Generator {
+	other := *g
+	other.budget = budget
+	return &other
+}
+
+// Lexicon returns the word lists g selects from.
+func (g *Generator) Lexicon() *lexicon.Lexicon {
+	return g.data.Lexicon
+}
+
+// Template returns the token tree g generates from.
+func (g *Generator) Template() token.StringConstructionToken {
+	return g.data.Token
+}
+
+// Budget returns the limit on the work for each name.
+func (g *Generator) Budget() token.Budget {
+	return g.budget
+}
+
+// For returns the context for generating a name of the given gender, with
+// g's budget.
+func (g *Generator) For(gender string) token.StringConstructionContext {
+	ctx := g.data.For(gender)
+	ctx.Budget = g.budget
+	return ctx
+}
+
+// MissingLists returns the lists the template selects from that the lexicon
+// does not define.
+func (g *Generator) MissingLists() []string {
+	return g.data.MissingLists()
+}
+
+// PickGender chooses the gender of a name whose request doesn't give one:
+// female or male 40% of the time each, and Other the rest.
+func PickGender(rand token.TokenRandomSource) string {
+	roll := rand.Float64()
+	if roll < 0.4 {
+		return lexicon.Female
+	} else if roll < 0.8 {
+		return lexicon.Male
+	}
+	return Other
+}
+
+// Request asks for one name.
+type Request struct {
+	Id     string `json:"id"`
+	Gender string `json:"gender"`
+}
+
+// Requests returns requests unchanged if there are any. Otherwise it makes
+// count requests with IDs "0", "1" and so on, all of the given gender, or of
+// genders chosen by PickGender if gender is empty.
+func Requests(requests []Request, count int, gender string, rand token.TokenRandomSource) []Request {
+	if len(requests) > 0 {
+		return requests
+	}
+	requests = make([]Request, 0, count)
+	for i := 0; i < count; i++ {
+		g := gender
+		if g == "" {
+			g = PickGender(rand)
+		}
+		requests = append(requests, Request{Id: strconv.Itoa(i), Gender: g})
+	}
+	return requests
+}
+
+// Result is the outcome of one request. If the name couldn't be generated,
+// Err says why.
+type Result struct {
+	Id   string
+	Name string
+	// Derivation shows how the name was built, if it was asked for.
+	Derivation *token.Derivation
+	Err        error
+}
+
+// Name generates one name of the given gender.
+func (g *Generator) Name(ctx context.Context, rand token.TokenRandomSource, gender string) (string, error) {
+	return token.Generate(ctx, g.data.Token, rand, g.For(gender))
+}
+
+// Explain generates one name of the given gender, and returns how it was
+// derived. The derivation is returned even if generation failed part way.
+func (g *Generator) Explain(ctx context.Context, rand token.TokenRandomSource, gender string) (string, *token.Derivation, error) {
+	return token.Trace(ctx, g.data.Token, rand, g.For(gender))
+}
+
+// Generate makes a name for each request in turn, drawing from rand. A
+// request that fails has its error in its Result and doesn't stop the rest.
+// If explain is set, each Result includes its derivation.
+func (g *Generator) Generate(ctx context.Context, rand token.TokenRandomSource, requests []Request, explain bool) []Result {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	results := make([]Result, 0, len(requests))
+	for _, request := range requests {
+		result := Result{Id: request.Id}
+		if explain {
+			result.Name, result.Derivation, result.Err = g.Explain(ctx, rand, request.Gender)
+		} else {
+			result.Name, result.Err = g.Name(ctx, rand, request.Gender)
+		}
+		if result.Err != nil {
+			result.Name = ""
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,139 @@
+package namegen
+
+import (
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"math/rand"
+	"testing"
+)
+
+func testLexicon() *lexicon.Lexicon {
+	lex := lexicon.New()
+	for _, name := range []string{"Ada", "Agnes", "Beth"} {
+		lex.Add("name", lexicon.Entry{Text: name, Tags: []string{lexicon.Female}})
+	}
+	for _, name := range []string{"Bob", "Carl", "Dan"} {
+		lex.Add("name", lexicon.Entry{Text: name, Tags: []string{lexicon.Male}})
+	}
+	lex.Add("noun", lexicon.Entry{Text: "wolf"})
+	lex.Add("noun", lexicon.Entry{Text: "raven"})
+	return lex
+}
+
+func testGenerator(t *testing.T, template string) *Generator {
+	tok, err := parser.ParseFrom(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(testLexicon(), tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestNew_invalid(t *testing.T) {
+	if _, err := New(nil, token.LiteralToken{Literal: "x"}); err == nil {
+		t.Errorf("Expected an error without a lexicon")
+	}
+	if _, err := New(testLexicon(), nil); err == nil {
+		t.Errorf("Expected an error without a template")
+	}
+	if _, err := New(testLexicon(), token.OrdinalSelectionToken{Max: 1}); err == nil {
+		t.Errorf("Expected an error for an ordinal with nothing to choose")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	g, err := Load([]byte("name@female\tnoun\nAda\twolf\n"), []byte(`$name " the " #noun`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	name, err := g.Name(context.Background(), rand.New(rand.NewSource(1)), lexicon.Female)
+	if err != nil || name != "Ada the wolf" {
+		t.Errorf("Expected 'Ada the wolf', got '%s' and %v", name, err)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	given := []Request{{Id: "a", Gender: lexicon.Male}}
+	if got := Requests(given, 5, "", rand.New(rand.NewSource(1))); !cmp.Equal(given, got) {
+		t.Errorf("Expected the given requests, got %v", got)
+	}
+
+	expected := []Request{{Id: "0", Gender: lexicon.Female}, {Id: "1", Gender: lexicon.Female}}
+	if diff := cmp.Diff(expected, Requests(nil, 2, lexicon.Female, nil)); diff != "" {
+		t.Errorf("Expected requests of the given gender (-want +got):\n%s", diff)
+	}
+
+	genders := map[string]int{}
+	for _, request := range Requests(nil, 1000, "", rand.New(rand.NewSource(1))) {
+		genders[request.Gender]++
+	}
+	if len(genders) != 3 || genders[Other] > genders[lexicon.Female] || genders[Other] > genders[lexicon.Male] {
+		t.Errorf("Expected mostly female and male requests and some other, got %v", genders)
+	}
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	g := testGenerator(t, `$name " the " #noun`)
+	requests := []Request{{Id: "f", Gender: lexicon.Female}, {Id: "m", Gender: lexicon.Male}}
+	results := g.Generate(context.Background(), rand.New(rand.NewSource(3)), requests, false)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %v", results)
+	}
+	if results[0].Id != "f" || results[0].Err != nil || results[0].Derivation != nil {
+		t.Errorf("Expected a female name without a derivation, got %+v", results[0])
+	}
+	if results[1].Id != "m" || results[1].Err != nil {
+		t.Errorf("Expected a male name, got %+v", results[1])
+	}
+
+	explained := g.Generate(context.Background(), rand.New(rand.NewSource(3)), requests, true)
+	for i, result := range explained {
+		if result.Name != results[i].Name || result.Derivation == nil || result.Derivation.Output != result.Name {
+			t.Errorf("Expected the same name with its derivation, got %+v", result)
+		}
+	}
+}
+
+func TestGenerator_Generate_errors(t *testing.T) {
+	g := testGenerator(t, `$name " the " $missing`)
+	results := g.Generate(context.Background(), rand.New(rand.NewSource(1)), []Request{{Id: "0"}, {Id: "1"}}, false)
+	for _, result := range results {
+		if !errors.Is(result.Err, token.ErrMissingList) || result.Name != "" {
+			t.Errorf("Expected a missing list error, got %+v", result)
+		}
+	}
+	if missing := g.MissingLists(); !cmp.Equal(missing, []string{"missing"}) {
+		t.Errorf("Expected the missing list to be reported, got %v", missing)
+	}
+}
+
+func TestGenerator_budget(t *testing.T) {
+	g := testGenerator(t, `$name " the " #noun`)
+	if g.Budget() != DefaultBudget {
+		t.Errorf("Expected the default budget, got %+v", g.Budget())
+	}
+	tight := g.WithBudget(token.Budget{MaxOutput: 3})
+	var budgetErr *token.BudgetError
+	if _, err := tight.Name(context.Background(), rand.New(rand.NewSource(1)), ""); !errors.As(err, &budgetErr) {
+		t.Errorf("Expected a budget error, got %v", err)
+	}
+	if g.Budget() != DefaultBudget {
+		t.Errorf("Expected the original generator to keep its budget")
+	}
+
+	tok, _ := parser.ParseFrom(`#noun`)
+	other, err := tight.WithTemplate(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Budget() != tight.Budget() || other.Lexicon() != g.Lexicon() || !cmp.Equal(other.Template(), tok) {
+		t.Errorf("Expected the new template with the same lexicon and budget")
+	}
+}
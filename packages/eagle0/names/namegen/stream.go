@@ -0,0 +1,169 @@
+package namegen
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// ChunkSize is how many names of a stream share a random stream. Changing it
+// changes which names a seed gives.
+const ChunkSize = 1000
+
+// StreamOptions are the parameters of a stream of names.
+type StreamOptions struct {
+	// Count is how many names to generate.
+	Count int
+	// Seed picks the names; the same seed gives the same names.
+	Seed int64
+	// Gender filters the word lists for every name. If empty, each name's
+	// gender is chosen by PickGender.
+	Gender string
+	// Workers is how many names are generated at once. Zero means one per
+	// CPU. It doesn't change which names are generated.
+	Workers int
+}
+
+// StreamedName is one name of a stream. Index is its position in the stream,
+// from 0. If the name couldn't be generated, Err says why.
+type StreamedName struct {
+	Index  int
+	Gender string
+	Name   string
+	Err    error
+}
+
+// chunkSeed derives the seed of a chunk's random stream from the overall
+// seed, mixing the two with SplitMix64 so neighbouring chunks are unrelated.
+func chunkSeed(seed int64, chunk int) int64 {
+	z := uint64(seed) + uint64(chunk+1)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return int64(z ^ (z >> 31))
+}
+
+// chunk generates the names of one chunk.
+func (g *Generator) chunk(ctx context.Context, opts StreamOptions, chunk int) []StreamedName {
+	start := chunk * ChunkSize
+	n := ChunkSize
+	if start+n > opts.Count {
+		n = opts.Count - start
+	}
+	rGen := rand.New(rand.NewSource(chunkSeed(opts.Seed, chunk)))
+	names := make([]StreamedName, n)
+	for i := range names {
+		gender := opts.Gender
+		if gender == "" {
+			gender = PickGender(rGen)
+		}
+		name, err := g.program.Generate(ctx, rGen, g.For(gender))
+		names[i] = StreamedName{Index: start + i, Gender: gender, Name: name, Err: err}
+	}
+	return names
+}
+
+type chunkResult struct {
+	chunk int
+	names []StreamedName
+}
+
+// Each generates a stream of names and calls yield with each in order. It
+// stops early, returning the error, if yield returns an error or ctx is done.
+func (g *Generator) Each(ctx context.Context, opts StreamOptions, yield func(StreamedName) error) error {
+	if opts.Count <= 0 {
+		return nil
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunks := (opts.Count + ChunkSize - 1) / ChunkSize
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// Chunks are handed out in order, and at most twice as many as there are
+	// workers are in flight, so finished chunks waiting for an earlier one
+	// don't pile up.
+	jobs := make(chan int)
+	results := make(chan chunkResult)
+	slots := make(chan struct{}, 2*workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				select {
+				case results <- chunkResult{chunk, g.chunk(ctx, opts, chunk)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for chunk := 0; chunk < chunks; chunk++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	pending := map[int][]StreamedName{}
+	for next := 0; next < chunks; {
+		select {
+		case result := <-results:
+			pending[result.chunk] = result.names
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		for names, ok := pending[next]; ok; names, ok = pending[next] {
+			delete(pending, next)
+			for _, name := range names {
+				if errors.Is(name.Err, context.Canceled) || errors.Is(name.Err, context.DeadlineExceeded) {
+					return name.Err
+				}
+				if err := yield(name); err != nil {
+					return err
+				}
+			}
+			<-slots
+			next++
+		}
+	}
+	return nil
+}
+
+// Stream generates the names in the background and sends them in order on
+// the returned channel, which is closed when they are all sent or ctx is
+// done. The caller must keep receiving until the channel is closed or cancel
+// ctx.
+func (g *Generator) Stream(ctx context.Context, opts StreamOptions) <-chan StreamedName {
+	names := make(chan StreamedName, ChunkSize)
+	go func() {
+		defer close(names)
+		g.Each(ctx, opts, func(name StreamedName) error {
+			select {
+			case names <- name:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return names
+}
@@ -0,0 +1,116 @@
+package namegen
+
+import (
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"testing"
+)
+
+func collect(t *testing.T, g *Generator, opts StreamOptions) []StreamedName {
+	names := []StreamedName{}
+	err := g.Each(context.Background(), opts, func(name StreamedName) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return names
+}
+
+func TestGenerator_Each_reproducibleAcrossWorkers(t *testing.T) {
+	g := testGenerator(t, `$name [0.5 " the ", 0.5 " of the "] -$noun+ {0.3 " " %100}`)
+	opts := StreamOptions{Count: 3*ChunkSize + 17, Seed: 42, Workers: 1}
+	expected := collect(t, g, opts)
+	if len(expected) != opts.Count {
+		t.Fatalf("Expected %d names, got %d", opts.Count, len(expected))
+	}
+	for i, name := range expected {
+		if name.Index != i || name.Err != nil || name.Name == "" {
+			t.Fatalf("Expected name %d in order without error, got %+v", i, name)
+		}
+	}
+
+	for _, workers := range []int{2, 5, 16} {
+		opts.Workers = workers
+		if diff := cmp.Diff(expected, collect(t, g, opts)); diff != "" {
+			t.Errorf("Expected the same names with %d workers (-want +got):\n%s", workers, diff)
+		}
+	}
+
+	opts.Seed = 43
+	if cmp.Equal(expected, collect(t, g, opts)) {
+		t.Errorf("Expected a different seed to give different names")
+	}
+}
+
+func TestGenerator_Each_filtersByGender(t *testing.T) {
+	g := testGenerator(t, `$name`)
+	for _, name := range collect(t, g, StreamOptions{Count: 500, Gender: lexicon.Male}) {
+		if name.Gender != lexicon.Male || (name.Name != "Bob" && name.Name != "Carl" && name.Name != "Dan") {
+			t.Fatalf("Expected a male name, got %+v", name)
+		}
+	}
+
+	genders := map[string]int{}
+	for _, name := range collect(t, g, StreamOptions{Count: 1000}) {
+		genders[name.Gender]++
+	}
+	if len(genders) != 3 {
+		t.Errorf("Expected a mix of genders, got %v", genders)
+	}
+}
+
+func TestGenerator_Each_stops(t *testing.T) {
+	g := testGenerator(t, `$name`)
+	stop := errors.New("stop")
+	seen := 0
+	err := g.Each(context.Background(), StreamOptions{Count: 10 * ChunkSize, Workers: 4}, func(name StreamedName) error {
+		seen++
+		if seen == 1500 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || seen != 1500 {
+		t.Errorf("Expected to stop after 1500 names, got %d and %v", seen, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.Each(ctx, StreamOptions{Count: 10 * ChunkSize}, func(StreamedName) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the generation to be cancelled, got %v", err)
+	}
+}
+
+func TestGenerator_Each_reportsNameErrors(t *testing.T) {
+	g := testGenerator(t, `$name $missing`)
+	names := collect(t, g, StreamOptions{Count: 3})
+	for _, name := range names {
+		if name.Err == nil {
+			t.Errorf("Expected an error, got %+v", name)
+		}
+	}
+}
+
+func TestGenerator_Stream(t *testing.T) {
+	g := testGenerator(t, `$name " " $noun`)
+	opts := StreamOptions{Count: 2500, Seed: 7, Workers: 3}
+	expected := collect(t, g, opts)
+	got := []StreamedName{}
+	for name := range g.Stream(context.Background(), opts) {
+		got = append(got, name)
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Expected the stream to match Each (-want +got):\n%s", diff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := g.Stream(ctx, StreamOptions{Count: 100 * ChunkSize})
+	<-stream
+	cancel()
+	for range stream {
+	}
+}
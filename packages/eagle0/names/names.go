@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/namegen"
 	"github.com/nolen777/name-generator/packages/eagle0/names/parser"
-	"github.com/nolen777/name-generator/packages/eagle0/names/spaces_fetcher"
 	"github.com/nolen777/name-generator/packages/eagle0/names/token"
 	"math/rand"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -23,23 +23,139 @@ type httpInfo struct {
 	Path    string  `json:"path"`
 }
 
-type NameRequest struct {
-	Id     string `json:"id"`
-	Gender string `json:"gender"`
+type NameRequest = namegen.Request
+
+// param holds a scalar request parameter. Query string parameters arrive as
+// strings while JSON bodies may use numbers, so both are accepted.
+type param string
+
+func (p *param) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*p = param(s)
+		return nil
+	}
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		raw = ""
+	}
+	*p = param(raw)
+	return nil
 }
 
 type Event struct {
 	Requests []NameRequest `json:"requests"`
+	Count    param         `json:"count"`
+	Gender   param         `json:"gender"`
+	Template param         `json:"template"`
+	AST      param         `json:"ast"`
+	Seed     param         `json:"seed"`
+	Version  param         `json:"version"`
+	Explain  param         `json:"explain"`
+	Bulk     param         `json:"bulk"`
 	Http     httpInfo      `json:"http"`
 }
 
+const defaultCount = 20
+const maxCount = 1000
+
+// options are the generation parameters shared by every request in an event.
+type options struct {
+	Count    int
+	Gender   string
+	Template string
+	// AST is a template compiled to the JSON form written by token.Marshal,
+	// used instead of Template.
+	AST  string
+	Seed string
+	// Version pins generation to a released data version instead of the
+	// current one.
+	Version string
+	// Explain adds the derivation of each name to the response.
+	Explain bool
+	// Bulk generates many names at once as NDJSON, using every CPU.
+	Bulk bool
+}
+
+func parseOptions(event Event) (options, error) {
+	opts := options{
+		Count:    defaultCount,
+		Gender:   strings.TrimSpace(string(event.Gender)),
+		Template: strings.TrimSpace(string(event.Template)),
+		AST:      strings.TrimSpace(string(event.AST)),
+		Seed:     strings.TrimSpace(string(event.Seed)),
+		Version:  strings.TrimSpace(string(event.Version)),
+	}
+	if len(event.Requests) > maxCount {
+		return opts, fmt.Errorf("at most %d requests may be made at once", maxCount)
+	}
+	if opts.Template != "" && opts.AST != "" {
+		return opts, fmt.Errorf("only one of template and ast may be given")
+	}
+	if bulk := strings.TrimSpace(string(event.Bulk)); bulk != "" {
+		b, err := strconv.ParseBool(bulk)
+		if err != nil {
+			return opts, fmt.Errorf("bulk must be true or false")
+		}
+		opts.Bulk = b
+	}
+	limit := maxCount
+	if opts.Bulk {
+		limit = maxBulkCount
+	}
+	if count := strings.TrimSpace(string(event.Count)); count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 || n > limit {
+			return opts, fmt.Errorf("count must be a number between 1 and %d", limit)
+		}
+		opts.Count = n
+	}
+	if explain := strings.TrimSpace(string(event.Explain)); explain != "" {
+		b, err := strconv.ParseBool(explain)
+		if err != nil {
+			return opts, fmt.Errorf("explain must be true or false")
+		}
+		opts.Explain = b
+	}
+	if opts.Bulk && opts.Explain {
+		return opts, fmt.Errorf("bulk names cannot be explained")
+	}
+	if opts.Bulk && len(event.Requests) > 0 {
+		return opts, fmt.Errorf("bulk names are generated by count, not from requests")
+	}
+	if opts.Seed != "" {
+		if _, err := strconv.ParseInt(opts.Seed, 10, 64); err != nil {
+			return opts, fmt.Errorf("seed must be an integer")
+		}
+	}
+	return opts, nil
+}
+
+// seed returns the requested seed, or one based on the time if none was
+// given.
+func (opts options) seed() int64 {
+	if seed, err := strconv.ParseInt(opts.Seed, 10, 64); err == nil {
+		return seed
+	}
+	return time.Now().UnixNano()
+}
+
+func (opts options) randomSource() rand.Source {
+	return rand.NewSource(opts.seed())
+}
+
 type ResponseHeaders struct {
 	ContentType string `json:"Content-Type"`
 }
 
 type NameResponse struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Version string   `json:"version,omitempty"`
+	Error   *problem `json:"error,omitempty"`
+	// Derivation shows how the name was built, if the request asked to
+	// explain.
+	Derivation *token.Derivation `json:"derivation,omitempty"`
 }
 
 type Response struct {
@@ -48,210 +164,112 @@ type Response struct {
 	Headers    ResponseHeaders `json:"headers"`
 }
 
-var femaleCtx token.StringConstructionContext
-var maleCtx token.StringConstructionContext
-var otherCtx token.StringConstructionContext
-
-var stringConstructionToken token.StringConstructionToken
-
-func init() {
-	var wg sync.WaitGroup
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		femaleCtx, maleCtx, otherCtx = generateContexts()
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		stringConstructionString := fetchStringConstructionToken()
-		tok, err := parser.ParseFrom(stringConstructionString)
-		if err != nil {
-			fmt.Println("Error parsing string construction token: ", err)
-			panic(err)
-		}
-		stringConstructionToken = tok
-	}()
-
-	wg.Wait()
-}
-
 func Names(ctx context.Context, event Event) Response {
 	info := event.Http
 	headers := info.Headers
 
-	rGen := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	// Get the requests
-	requests := generateRequests(event, rGen)
-
-	nameResponses := []NameResponse{}
-	for _, request := range requests {
-		scCtx := otherCtx
-		if request.Gender == "female" {
-			scCtx = femaleCtx
-		} else if request.Gender == "male" {
-			scCtx = maleCtx
-		}
-		name, err := stringConstructionToken.Next(rGen, scCtx)
-		if err != nil {
-			fmt.Println("Error generating name: ", err)
-			return Response{
-				Body:       "<html><h1>Error generating name</h1></html>",
-				StatusCode: "500",
-				Headers: ResponseHeaders{
-					ContentType: "text/html",
-				},
-			}
-		}
-		nameResponses = append(nameResponses, NameResponse{
-			Id:   request.Id,
-			Name: name,
-		})
-	}
-
-	if headers.Accept == "application/json" {
-		fmt.Println("returning json")
-		return jsonSuccess(nameResponses)
-	}
-	if headers.Accept == "text/html" {
-		fmt.Println("returning html")
+	r, ok := negotiateRenderer(headers.Accept)
+	if !ok {
+		fmt.Println("No acceptable content type for", headers.Accept)
+		return notAcceptable()
 	}
-	return htmlSuccess(nameResponses)
-}
 
-type jsonBody struct {
-	Names []NameResponse `json:"names"`
-}
-
-func jsonSuccess(nameResponses []NameResponse) Response {
-	bodyObj, err := json.Marshal(jsonBody{Names: nameResponses})
+	opts, err := parseOptions(event)
 	if err != nil {
-		fmt.Println("Error marshalling JSON: ", err)
-		return Response{
-			Body:       "<html><h1>Error marshalling JSON</h1></html>",
-			StatusCode: "500",
-			Headers: ResponseHeaders{
-				ContentType: "text/html",
-			},
-		}
+		fmt.Println("Invalid request: ", err)
+		return errorResponse(headers.Accept, badRequestError(err))
 	}
-	return Response{
-		Body:       string(bodyObj),
-		StatusCode: "200",
-		Headers: ResponseHeaders{
-			ContentType: "application/json",
-		},
+	if opts.Bulk {
+		if r, ok = negotiateBulkRenderer(headers.Accept); !ok {
+			fmt.Println("No acceptable content type for bulk names in", headers.Accept)
+			return notAcceptable()
+		}
 	}
-}
 
-func htmlSuccess(nameResponses []NameResponse) Response {
-	names := make([]string, len(nameResponses))
-	for i, nameResponse := range nameResponses {
-		names[i] = nameResponse.Name
+	var snap *snapshot
+	if opts.Version != "" {
+		snap, err = data.Pinned(opts.Version)
+	} else {
+		snap, err = data.Snapshot()
 	}
-	return Response{
-		Body:       "<html>\r\n" + strings.Join(names, "\r\n<p>\r\n") + "</html>\r\n",
-		StatusCode: "200",
-		Headers: ResponseHeaders{
-			ContentType: "text/html",
-		},
+	if err != nil {
+		fmt.Println("Error loading data: ", err)
+		return errorResponse(headers.Accept, err)
 	}
-}
 
-func generateRequests(event Event, rGen *rand.Rand) []NameRequest {
-	requests := event.Requests
-	if len(requests) == 0 {
-		fmt.Println("No requests found")
-		for i := 0; i < 20; i++ {
-			roll := rGen.Float64()
-			gender := "other"
-			if roll < 0.4 {
-				gender = "female"
-			} else if roll < 0.8 {
-				gender = "male"
-			}
-			requests = append(requests, NameRequest{
-				Id:     fmt.Sprintf("%d", i),
-				Gender: gender,
-			})
+	generator := snap.Generator
+	if opts.Template != "" {
+		var nameToken token.StringConstructionToken
+		nameToken, err = parser.ParseFrom(opts.Template)
+		if err == nil {
+			generator, err = generator.WithTemplate(nameToken)
+		}
+		if err != nil {
+			fmt.Println("Invalid template: ", err)
+			return errorResponse(headers.Accept, badRequestError(fmt.Errorf("invalid template: %w", err)))
+		}
+	} else if opts.AST != "" {
+		var nameToken token.StringConstructionToken
+		nameToken, err = token.Unmarshal([]byte(opts.AST))
+		if err == nil {
+			generator, err = generator.WithTemplate(nameToken)
+		}
+		if err != nil {
+			fmt.Println("Invalid ast: ", err)
+			return errorResponse(headers.Accept, badRequestError(fmt.Errorf("invalid ast: %w", err)))
 		}
 	}
-	return requests
-}
-
-func generateContexts() (token.StringConstructionContext, token.StringConstructionContext, token.StringConstructionContext) {
-	femaleNameWords := map[string][]string{}
-	maleNameWords := map[string][]string{}
-	unfilteredNameWords := map[string][]string{}
 
-	namesTsvBytes, err := spaces_fetcher.GetFile("names.tsv")
-	if err != nil {
-		panic(err)
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	namesTsv := string(namesTsvBytes)
-
-	nameLines := strings.Split(namesTsv, "\r\n")
-	nameTitles := strings.Split(nameLines[0], "\t")
-	titleBuckets := make([]string, len(nameTitles))
-	for i := range nameTitles {
-		components := strings.Split(nameTitles[i], "@")
-		nameTitles[i] = components[0]
-		titleBuckets[i] = ""
-		if len(components) > 1 {
-			titleBuckets[i] = components[1]
+	var results []namegen.Result
+	if opts.Bulk {
+		results, err = bulkResults(ctx, generator, opts)
+		if err != nil {
+			fmt.Println("Error generating bulk names: ", err)
+			return errorResponse(headers.Accept, err)
+		}
+	} else {
+		rGen := rand.New(opts.randomSource())
+		if len(event.Requests) == 0 {
+			fmt.Println("No requests found")
 		}
-		maleNameWords[nameTitles[i]] = []string{}
-		femaleNameWords[nameTitles[i]] = []string{}
-		unfilteredNameWords[nameTitles[i]] = []string{}
+		requests := namegen.Requests(event.Requests, opts.Count, opts.Gender, rGen)
+		results = generator.Generate(ctx, rGen, requests, opts.Explain)
 	}
-	for _, line := range nameLines[1:] {
-		for i, entry := range strings.Split(line, "\t") {
-			if entry == "" {
-				continue
-			}
-			title := nameTitles[i]
-			bucket := titleBuckets[i]
 
-			unfilteredNameWords[title] = append(unfilteredNameWords[title], entry)
-			switch bucket {
-			case "female":
-				femaleNameWords[title] = append(femaleNameWords[title], entry)
-				break
-			case "male":
-				maleNameWords[title] = append(maleNameWords[title], entry)
-				break
-			default:
-				femaleNameWords[title] = append(femaleNameWords[title], entry)
-				maleNameWords[title] = append(maleNameWords[title], entry)
-				break
+	// A failing request does not abort the batch; its error is reported in its
+	// own NameResponse, and the response only fails if every request did.
+	nameResponses := []NameResponse{}
+	var firstErr error
+	failures := 0
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Println("Error generating name: ", result.Err)
+			if firstErr == nil {
+				firstErr = result.Err
 			}
+			failures++
+			nameResponses = append(nameResponses, NameResponse{
+				Id:         result.Id,
+				Version:    snap.Version,
+				Error:      classify(result.Err).problem(),
+				Derivation: result.Derivation,
+			})
+			continue
 		}
+		nameResponses = append(nameResponses, NameResponse{
+			Id:         result.Id,
+			Name:       result.Name,
+			Version:    snap.Version,
+			Derivation: result.Derivation,
+		})
 	}
-	maleCtx := token.StringConstructionContext{
-		ChoiceListMap:           maleNameWords,
-		UnfilteredChoiceListMap: unfilteredNameWords,
-	}
-	femaleCtx := token.StringConstructionContext{
-		ChoiceListMap:           femaleNameWords,
-		UnfilteredChoiceListMap: unfilteredNameWords,
-	}
-	otherCtx := token.StringConstructionContext{
-		ChoiceListMap:           unfilteredNameWords,
-		UnfilteredChoiceListMap: unfilteredNameWords,
-	}
-
-	return femaleCtx, maleCtx, otherCtx
-}
 
-func fetchStringConstructionToken() string {
-	rawStringConstructionToken, err := spaces_fetcher.GetFile("nameConstruction.txt")
-	if err != nil {
-		panic(err)
+	if failures > 0 && failures == len(results) {
+		return errorResponse(headers.Accept, firstErr)
 	}
-	removeCRs := strings.ReplaceAll(string(rawStringConstructionToken), "\r", "")
-	removeNLs := strings.ReplaceAll(removeCRs, "\n", "")
-	return removeNLs
+
+	return r.respond(output{Names: nameResponses, Options: opts})
 }
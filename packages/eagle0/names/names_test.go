@@ -2,20 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"strconv"
 	"strings"
 	"testing"
 )
 
 func nameCount(html string) int {
-	lines := strings.Split(html, "\r\n")
-	count := 0
-	for _, line := range lines {
-		if len(line) > 0 && line[0] != '<' {
-			count++
-		}
-	}
-	return count
+	return strings.Count(html, `<span class="name">`)
 }
 
 func TestNames_noParams(t *testing.T) {
@@ -89,3 +88,630 @@ func TestNames_noSpaceBeforeComma(t *testing.T) {
 		t.Errorf("Expected no space before comma in HTML response")
 	}
 }
+
+func TestNames_acceptJsonWithQualityValues(t *testing.T) {
+	event := Event{
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json, text/plain;q=0.9",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.Headers.ContentType != "application/json" {
+		t.Fatalf("Expected content type 'application/json', got '%s'", response.Headers.ContentType)
+	}
+
+	var jb jsonBody
+	err := json.Unmarshal([]byte(response.Body), &jb)
+	if err != nil {
+		t.Errorf("Expected valid JSON, got error: %v", err)
+	}
+}
+
+func TestNames_acceptPlainText(t *testing.T) {
+	event := Event{
+		Http: httpInfo{Headers: headers{
+			Accept: "text/plain",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.Headers.ContentType != "text/plain" {
+		t.Fatalf("Expected content type 'text/plain', got '%s'", response.Headers.ContentType)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(response.Body, "\n"), "\n")
+	if len(lines) != 20 {
+		t.Errorf("Expected 20 lines, got %d", len(lines))
+	}
+}
+
+func TestNames_acceptCsv(t *testing.T) {
+	event := Event{
+		Requests: []NameRequest{
+			{Id: "a", Gender: "female"},
+			{Id: "b", Gender: "male"},
+		},
+		Http: httpInfo{Headers: headers{
+			Accept: "text/csv",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.Headers.ContentType != "text/csv" {
+		t.Fatalf("Expected content type 'text/csv', got '%s'", response.Headers.ContentType)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(response.Body)).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got error: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 records, got %d", len(records))
+	}
+	if records[1][0] != "a" || records[2][0] != "b" {
+		t.Errorf("Expected ids 'a' and 'b', got '%s' and '%s'", records[1][0], records[2][0])
+	}
+}
+
+func TestNames_acceptNdjson(t *testing.T) {
+	event := Event{
+		Http: httpInfo{Headers: headers{
+			Accept: "application/x-ndjson",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.Headers.ContentType != "application/x-ndjson" {
+		t.Fatalf("Expected content type 'application/x-ndjson', got '%s'", response.Headers.ContentType)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(response.Body, "\n"), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("Expected 20 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var nr NameResponse
+		if err := json.Unmarshal([]byte(line), &nr); err != nil {
+			t.Errorf("Expected valid JSON line, got error: %v", err)
+		}
+	}
+}
+
+func TestNames_bulk(t *testing.T) {
+	event := Event{Count: "2500", Seed: "7", Bulk: "true"}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "200" || response.Headers.ContentType != "application/x-ndjson" {
+		t.Fatalf("Expected NDJSON, got %s %s: %s", response.StatusCode, response.Headers.ContentType, response.Body)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(response.Body, "\n"), "\n")
+	if len(lines) != 2500 {
+		t.Fatalf("Expected 2500 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var nr NameResponse
+		if err := json.Unmarshal([]byte(line), &nr); err != nil {
+			t.Fatalf("Expected valid JSON line, got error: %v", err)
+		}
+		if nr.Id != strconv.Itoa(i) || nr.Name == "" {
+			t.Fatalf("Expected name %d in order, got %+v", i, nr)
+		}
+	}
+	if again := Names(ctx, event).Body; again != response.Body {
+		t.Errorf("Expected the same names for the same seed")
+	}
+}
+
+func TestNames_bulkInvalid(t *testing.T) {
+	for _, event := range []Event{
+		{Bulk: "maybe"},
+		{Bulk: "true", Count: "10001"},
+		{Bulk: "true", Explain: "true"},
+		{Bulk: "true", Requests: []NameRequest{{Id: "a"}}},
+	} {
+		ctx := context.WithValue(context.Background(), "function_version", "1.0")
+		if response := Names(ctx, event); response.StatusCode != "400" {
+			t.Errorf("Expected status code to be '400' for %+v, got '%s'", event, response.StatusCode)
+		}
+	}
+
+	event := Event{Bulk: "true", Http: httpInfo{Headers: headers{Accept: "text/csv"}}}
+	if response := Names(context.Background(), event); response.StatusCode != "406" {
+		t.Errorf("Expected status code to be '406' for bulk CSV, got '%s'", response.StatusCode)
+	}
+}
+
+func TestNames_acceptXml(t *testing.T) {
+	event := Event{
+		Http: httpInfo{Headers: headers{
+			Accept: "application/xml",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.Headers.ContentType != "application/xml" {
+		t.Fatalf("Expected content type 'application/xml', got '%s'", response.Headers.ContentType)
+	}
+
+	var xb xmlBody
+	if err := xml.Unmarshal([]byte(response.Body), &xb); err != nil {
+		t.Fatalf("Expected valid XML, got error: %v", err)
+	}
+	if len(xb.Names) != 20 {
+		t.Errorf("Expected 20 names, got %d", len(xb.Names))
+	}
+}
+
+func TestNames_notAcceptable(t *testing.T) {
+	event := Event{
+		Http: httpInfo{Headers: headers{
+			Accept: "image/png",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "406" {
+		t.Errorf("Expected status code to be '406', got '%s'", response.StatusCode)
+	}
+	if response.Headers.ContentType != "text/plain" {
+		t.Errorf("Expected content type 'text/plain', got '%s'", response.Headers.ContentType)
+	}
+}
+
+func TestRenderHtml_escapesNames(t *testing.T) {
+	body, err := renderHtml(output{
+		Names:   []NameResponse{{Id: "1", Name: "<script>alert(1)</script> & Sons"}},
+		Options: options{Count: 1},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(body, "<script>alert") {
+		t.Errorf("Expected name to be escaped, got %s", body)
+	}
+	if !strings.Contains(body, "&lt;script&gt;alert(1)&lt;/script&gt; &amp; Sons") {
+		t.Errorf("Expected escaped name in body, got %s", body)
+	}
+	if !strings.HasPrefix(body, "<!DOCTYPE html>") {
+		t.Errorf("Expected an HTML document, got %s", body)
+	}
+}
+
+func TestRenderHtml_echoesOptions(t *testing.T) {
+	body, err := renderHtml(output{
+		Options: options{Count: 7, Gender: "male", Template: `$name " the " $adjective`, Seed: "42"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, expected := range []string{
+		`value="7"`,
+		`<option value="male" selected>`,
+		`value="42"`,
+		`$name &#34; the &#34; $adjective</textarea>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected body to contain %s", expected)
+		}
+	}
+}
+
+func TestRenderText_reportsFailedNames(t *testing.T) {
+	body, err := renderText(output{Names: []NameResponse{
+		{Id: "0", Name: "Ada"},
+		{Id: "1", Error: classify(fmt.Errorf("%w: surname", token.ErrEmptyList)).problem()},
+		{Id: "2", Name: "Bob"},
+	}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "Ada\nerror: Unsatisfiable constraint: empty list: surname\nBob\n"
+	if body != expected {
+		t.Errorf("Expected %q, got %q", expected, body)
+	}
+}
+
+func TestNames_countParam(t *testing.T) {
+	event := Event{Count: "5"}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "200" {
+		t.Errorf("Expected status code to be '200', got '%s'", response.StatusCode)
+	}
+
+	count := nameCount(response.Body)
+	if count != 5 {
+		t.Errorf("Expected body to contain 5 names, got '%d'", count)
+	}
+}
+
+func TestNames_invalidCount(t *testing.T) {
+	event := Event{Count: "lots"}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "400" {
+		t.Errorf("Expected status code to be '400', got '%s'", response.StatusCode)
+	}
+}
+
+func TestNames_seedIsReproducible(t *testing.T) {
+	event := Event{
+		Seed: "1234",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	first := Names(ctx, event).Body
+	second := Names(ctx, event).Body
+	if first != second {
+		t.Errorf("Expected identical responses for the same seed, got %s and %s", first, second)
+	}
+}
+
+func TestNames_templateParam(t *testing.T) {
+	event := Event{
+		Count:    "3",
+		Template: `"Sir " -$noun+`,
+		Http: httpInfo{Headers: headers{
+			Accept: "text/plain",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	for _, name := range strings.Split(strings.TrimSuffix(response.Body, "\n"), "\n") {
+		if !strings.HasPrefix(name, "Sir ") {
+			t.Errorf("Expected name to start with 'Sir ', got '%s'", name)
+		}
+	}
+}
+
+func TestNames_invalidTemplate(t *testing.T) {
+	event := Event{Template: `[0.5 "unclosed"`}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "400" {
+		t.Errorf("Expected status code to be '400', got '%s'", response.StatusCode)
+	}
+}
+
+func TestEvent_numericParams(t *testing.T) {
+	var event Event
+	err := json.Unmarshal([]byte(`{"count": 3, "seed": "9", "gender": "female"}`), &event)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	opts, err := parseOptions(event)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if opts.Count != 3 || opts.Seed != "9" || opts.Gender != "female" {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+}
+
+func TestNames_badRequestProblemDetails(t *testing.T) {
+	event := Event{
+		Count: "0",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "400" {
+		t.Errorf("Expected status code to be '400', got '%s'", response.StatusCode)
+	}
+	if response.Headers.ContentType != "application/problem+json" {
+		t.Errorf("Expected content type 'application/problem+json', got '%s'", response.Headers.ContentType)
+	}
+
+	var p problem
+	if err := json.Unmarshal([]byte(response.Body), &p); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if p.Status != 400 || p.Type != "urn:eagle0:names:problem:bad-request" || p.Detail == "" {
+		t.Errorf("Unexpected problem %+v", p)
+	}
+}
+
+func TestNames_plainTextProblem(t *testing.T) {
+	event := Event{
+		Count: "0",
+		Http: httpInfo{Headers: headers{
+			Accept: "text/csv",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "400" {
+		t.Errorf("Expected status code to be '400', got '%s'", response.StatusCode)
+	}
+	if response.Headers.ContentType != "text/plain" {
+		t.Errorf("Expected content type 'text/plain', got '%s'", response.Headers.ContentType)
+	}
+	if !strings.HasPrefix(response.Body, "Bad request: ") || strings.Contains(response.Body, "<html>") {
+		t.Errorf("Unexpected body %q", response.Body)
+	}
+}
+
+func TestNames_unknownList(t *testing.T) {
+	event := Event{
+		Template: "$no_such_list",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "422" {
+		t.Errorf("Expected status code to be '422', got '%s'", response.StatusCode)
+	}
+
+	var p problem
+	if err := json.Unmarshal([]byte(response.Body), &p); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if p.Type != "urn:eagle0:names:problem:unknown-list" {
+		t.Errorf("Unexpected problem %+v", p)
+	}
+}
+
+func TestNames_budgetExceeded(t *testing.T) {
+	event := Event{
+		Template: param(strings.Repeat("[1.0 ", 200) + `"deep"` + strings.Repeat("]", 200)),
+		Count:    "1",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	response := Names(context.Background(), event)
+	if response.StatusCode != "422" {
+		t.Fatalf("Expected status code to be '422', got '%s': %s", response.StatusCode, response.Body)
+	}
+
+	var p problem
+	if err := json.Unmarshal([]byte(response.Body), &p); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if p.Type != "urn:eagle0:names:problem:budget-exceeded" {
+		t.Errorf("Unexpected problem %+v", p)
+	}
+}
+
+func TestNames_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	event := Event{
+		Count: "2",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	response := Names(ctx, event)
+	if response.StatusCode != "503" {
+		t.Errorf("Expected status code to be '503', got '%s': %s", response.StatusCode, response.Body)
+	}
+}
+
+func TestNames_partialSuccess(t *testing.T) {
+	useStore(t, newMemStore(map[string]string{
+		namesTsvPath:         "name@male\r\nBob",
+		nameConstructionPath: "$name",
+	}))
+
+	event := Event{
+		Requests: []NameRequest{
+			{Id: "f", Gender: "female"},
+			{Id: "m", Gender: "male"},
+		},
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "200" {
+		t.Fatalf("Expected status code to be '200', got '%s'", response.StatusCode)
+	}
+
+	var jb jsonBody
+	if err := json.Unmarshal([]byte(response.Body), &jb); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if len(jb.Names) != 2 {
+		t.Fatalf("Expected 2 names, got %d", len(jb.Names))
+	}
+	if jb.Names[0].Error == nil || jb.Names[0].Error.Status != 422 {
+		t.Errorf("Expected first name to carry a 422 error, got %+v", jb.Names[0])
+	}
+	if jb.Names[1].Error != nil || jb.Names[1].Name != "Bob" {
+		t.Errorf("Expected second name to be 'Bob', got %+v", jb.Names[1])
+	}
+}
+
+func TestNames_reportsDataVersion(t *testing.T) {
+	store := newMemStore(map[string]string{
+		namesTsvPath:         "name\r\nBob",
+		nameConstructionPath: "$name",
+	})
+	useStore(t, store)
+	version, err := storeVersion(store)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	event := Event{
+		Count: "2",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	var jb jsonBody
+	if err := json.Unmarshal([]byte(Names(ctx, event).Body), &jb); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	for _, nr := range jb.Names {
+		if nr.Version != version {
+			t.Errorf("Expected version '%s', got '%s'", version, nr.Version)
+		}
+	}
+}
+
+func TestClassify_internalErrorsHideDetails(t *testing.T) {
+	p := classify(errors.New("disk on fire")).problem()
+	if p.Status != 500 {
+		t.Errorf("Expected status 500, got %d", p.Status)
+	}
+	if p.Detail != "" {
+		t.Errorf("Expected no detail for internal errors, got '%s'", p.Detail)
+	}
+}
+
+func TestNames_pinnedVersion(t *testing.T) {
+	store, ids := releaseStore(
+		[2]string{"name\r\nAda", "$name"},
+		[2]string{"name\r\nBob", "$name"},
+	)
+	useStore(t, store)
+
+	event := Event{
+		Count:   "1",
+		Version: param(ids[0]),
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	var jb jsonBody
+	if err := json.Unmarshal([]byte(Names(ctx, event).Body), &jb); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if len(jb.Names) != 1 || jb.Names[0].Name != "Ada" || jb.Names[0].Version != ids[0] {
+		t.Errorf("Expected 'Ada' from version '%s', got %+v", ids[0], jb.Names)
+	}
+}
+
+func TestNames_unknownVersion(t *testing.T) {
+	store, _ := releaseStore([2]string{"name\r\nAda", "$name"})
+	useStore(t, store)
+
+	event := Event{
+		Version: "fedcba9876543210",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "404" {
+		t.Errorf("Expected status code to be '404', got '%s'", response.StatusCode)
+	}
+}
+
+func TestNames_unpinnableVersion(t *testing.T) {
+	useStore(t, newMemStore(map[string]string{}))
+
+	event := Event{
+		Version: "0123456789ab",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "400" {
+		t.Errorf("Expected status code to be '400', got '%s'", response.StatusCode)
+	}
+	if !strings.Contains(response.Body, "cannot be pinned") {
+		t.Errorf("Expected the error to say the version can't be pinned, got %s", response.Body)
+	}
+}
+
+func TestNames_astParam(t *testing.T) {
+	var event Event
+	body := `{"count": 3, "ast": {"type": "sequence", "tokens": [
+		{"type": "literal", "literal": "Sir "},
+		{"type": "titlecase", "token": {"type": "list", "list": "noun"}}
+	]}, "http": {"headers": {"accept": "text/plain"}}}`
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "200" {
+		t.Fatalf("Expected status code to be '200', got '%s': %s", response.StatusCode, response.Body)
+	}
+	for _, name := range strings.Split(strings.TrimSuffix(response.Body, "\n"), "\n") {
+		if !strings.HasPrefix(name, "Sir ") {
+			t.Errorf("Expected name to start with 'Sir ', got '%s'", name)
+		}
+	}
+}
+
+func TestNames_invalidAst(t *testing.T) {
+	for _, event := range []Event{
+		{AST: `{"type": "list"}`},
+		{AST: `{"type": "literal", "literal": "a"}`, Template: `"a"`},
+	} {
+		ctx := context.WithValue(context.Background(), "function_version", "1.0")
+		response := Names(ctx, event)
+		if response.StatusCode != "400" {
+			t.Errorf("Expected status code to be '400' for %+v, got '%s'", event, response.StatusCode)
+		}
+	}
+}
+
+func TestNames_explain(t *testing.T) {
+	event := Event{
+		Count:    "5",
+		Template: `$name {1.0 " the " -$adjective+}`,
+		Explain:  "true",
+		Http: httpInfo{Headers: headers{
+			Accept: "application/json",
+		}},
+	}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	response := Names(ctx, event)
+	if response.StatusCode != "200" {
+		t.Fatalf("Expected status code to be '200', got '%s': %s", response.StatusCode, response.Body)
+	}
+
+	var jb jsonBody
+	if err := json.Unmarshal([]byte(response.Body), &jb); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	for _, name := range jb.Names {
+		d := name.Derivation
+		if d == nil || d.Type != "sequence" || d.Output != name.Name || len(d.Children) != 2 {
+			t.Fatalf("Expected a sequence derivation of %q, got %+v", name.Name, d)
+		}
+		if list := d.Children[0]; list.List != "name" || list.Index == nil || list.Choices == 0 {
+			t.Errorf("Expected the chosen name entry, got %+v", list)
+		}
+		if optional := d.Children[1]; optional.Fired == nil || !*optional.Fired {
+			t.Errorf("Expected the optional to fire, got %+v", optional)
+		}
+	}
+}
+
+func TestNames_explainOffByDefault(t *testing.T) {
+	for _, explain := range []param{"", "false"} {
+		event := Event{Count: "2", Explain: explain, Http: httpInfo{Headers: headers{Accept: "application/json"}}}
+		ctx := context.WithValue(context.Background(), "function_version", "1.0")
+		response := Names(ctx, event)
+		if strings.Contains(response.Body, "derivation") {
+			t.Errorf("Expected no derivations for explain=%q, got %s", explain, response.Body)
+		}
+	}
+
+	event := Event{Explain: "maybe"}
+	ctx := context.WithValue(context.Background(), "function_version", "1.0")
+	if response := Names(ctx, event); response.StatusCode != "400" {
+		t.Errorf("Expected status code to be '400', got '%s'", response.StatusCode)
+	}
+}
@@ -1,13 +1,60 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"reflect"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
+// SyntaxError reports a problem reading a template, such as an unclosed
+// literal or an unknown escape, and where it is.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// offsetError is a syntax error at a byte offset into the text being read. It
+// is turned into a SyntaxError once the line and column are known.
+type offsetError struct {
+	offset int
+	msg    string
+}
+
+func (e *offsetError) Error() string {
+	return e.msg
+}
+
+// syntaxError returns err positioned within source, given that it occurred
+// while reading source[offset:].
+func syntaxError(source string, offset int, err error) error {
+	var oe *offsetError
+	if !errors.As(err, &oe) {
+		return err
+	}
+	offset += oe.offset
+	line := 1 + strings.Count(source[:offset], "\n")
+	column := 1 + utf8.RuneCountInString(source[strings.LastIndex(source[:offset], "\n")+1:offset])
+	return &SyntaxError{Line: line, Column: column, Msg: oe.msg}
+}
+
+// ParseFrom parses a template. Spaces, tabs and line breaks between tokens
+// are ignored, as is anything from // to the end of the line outside a
+// literal.
+//
+// Literals are written in double quotes, where \", \\, \n and \u{hex}
+// stand for a quote, a backslash, a line break and any Unicode character, or
+// in backquotes, where every character up to the closing backquote is taken
+// as it is, including line breaks.
 func ParseFrom(formatString string) (token.StringConstructionToken, error) {
 	result, err := parseNext(formatString, parseSequence{})
 	if err != nil {
@@ -19,28 +66,37 @@ func ParseFrom(formatString string) (token.StringConstructionToken, error) {
 		return nil, err
 	}
 	if len(parsedResult.Remaining) != 0 {
-		return nil, err
+		return nil, fmt.Errorf("Unexpected %s outside a oneof list", ToString(parsedResult.Remaining[:1]))
 	}
 	return parsedResult.ParsedToken, nil
 }
 
 func parseNext(remaining string, acc parseSequence) (parseSequence, error) {
-	if remaining == "" {
-		return acc, nil
-	}
-
-	switch remaining[0] {
-	case ' ', '\n':
-		return parseNext(remaining[1:], acc)
-	case '"':
-		newRemaining, tok, err := parseLiteral(remaining)
-		if err != nil {
-			return nil, err
+	source := remaining
+	for remaining != "" {
+		switch {
+		case strings.HasPrefix(remaining, "//"):
+			end := strings.IndexAny(remaining, "\r\n")
+			if end < 0 {
+				end = len(remaining)
+			}
+			remaining = remaining[end:]
+		case strings.IndexByte(" \t\r\n", remaining[0]) >= 0:
+			remaining = remaining[1:]
+		case remaining[0] == '"' || remaining[0] == '`':
+			newRemaining, tok, err := parseLiteral(remaining)
+			if err != nil {
+				return nil, syntaxError(source, len(source)-len(remaining), err)
+			}
+			acc = append(acc, t{tok})
+			remaining = newRemaining
+		default:
+			r, size := utf8.DecodeRuneInString(remaining)
+			acc = append(acc, character{r})
+			remaining = remaining[size:]
 		}
-		return parseNext(newRemaining, append(acc, t{tok}))
-	default:
-		return parseNext(remaining[1:], append(acc, character{rune(remaining[0])}))
 	}
+	return acc, nil
 }
 
 type characterOrToken interface {
@@ -100,26 +156,71 @@ type parseResult struct {
 	ParsedToken token.StringConstructionToken
 }
 
+// parseLiteral reads a literal in double quotes or backquotes from the start
+// of formatString, returning the text after it.
 func parseLiteral(formatString string) (string, token.LiteralToken, error) {
+	if strings.HasPrefix(formatString, "`") {
+		end := strings.IndexByte(formatString[1:], '`')
+		if end < 0 {
+			return formatString, token.LiteralToken{}, &offsetError{0, "Missing closing ` in literal"}
+		}
+		literal := strings.ReplaceAll(formatString[1:end+1], "\r\n", "\n")
+		return formatString[end+2:], token.LiteralToken{Literal: literal}, nil
+	}
 	if !strings.HasPrefix(formatString, "\"") {
 		return formatString, token.LiteralToken{}, nil
 	}
-	remaining := formatString[1:]
-	acc := ""
 
-	newRemaining := remaining
-	for i, r := range remaining {
-		newRemaining = remaining[i:]
+	var acc strings.Builder
+	for i := 1; i < len(formatString); {
+		r, size := utf8.DecodeRuneInString(formatString[i:])
 		switch r {
 		case '"':
-			return newRemaining[1:], token.LiteralToken{Literal: acc}, nil
-
+			return formatString[i+1:], token.LiteralToken{Literal: acc.String()}, nil
+		case '\r', '\n':
+			return formatString, token.LiteralToken{}, &offsetError{i, "Line break in literal; use \\n or a backquoted literal"}
+		case '\\':
+			escaped, escapeSize, err := readEscape(formatString[i:])
+			if err != nil {
+				return formatString, token.LiteralToken{}, &offsetError{i, err.Error()}
+			}
+			acc.WriteRune(escaped)
+			i += escapeSize
 		default:
-			acc += string(r)
+			acc.WriteRune(r)
+			i += size
 		}
 	}
 
-	return formatString, token.LiteralToken{}, fmt.Errorf("Missing closing \" in literal")
+	return formatString, token.LiteralToken{}, &offsetError{0, "Missing closing \" in literal"}
+}
+
+// readEscape reads the escape sequence at the start of s, returning the
+// character it stands for and its length.
+func readEscape(s string) (rune, int, error) {
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("Unfinished escape")
+	}
+	switch s[1] {
+	case '"', '\\':
+		return rune(s[1]), 2, nil
+	case 'n':
+		return '\n', 2, nil
+	case 'u':
+		end := strings.IndexByte(s, '}')
+		if !strings.HasPrefix(s[2:], "{") || end < 0 {
+			return 0, 0, fmt.Errorf("Expected \\u{hex}")
+		}
+		digits := s[3:end]
+		value, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(value)) {
+			return 0, 0, fmt.Errorf("Invalid character code \\u{%s}", digits)
+		}
+		return rune(value), end + 1, nil
+	default:
+		r, _ := utf8.DecodeRuneInString(s[1:])
+		return 0, 0, fmt.Errorf("Unknown escape \\%c", r)
+	}
 }
 
 func insideBalanced(ts parseSequence, open rune, closed rune) (parseSequence, parseSequence, error) {
@@ -223,6 +324,9 @@ func parseOrdinal(ts parseSequence) (parseResult, error) {
 	if err != nil {
 		return parseResult{ts, nil}, fmt.Errorf("Invalid ordinal max value: %s", maxString)
 	}
+	if maxVal < 2 {
+		return parseResult{ts, nil}, fmt.Errorf("Ordinal max value %d must be at least 2", maxVal)
+	}
 	return parseResult{Remaining: ts, ParsedToken: token.OrdinalSelectionToken{Max: maxVal}}, nil
 }
 
@@ -255,7 +359,11 @@ func parseOptional(ts parseSequence) (parseResult, error) {
 		return parseResult{ts, nil}, fmt.Errorf("Expected empty remaining after inner parse")
 	}
 
-	return parseResult{Remaining: remaining, ParsedToken: token.OptionalToken{Odds: dec, Token: innerTok.ParsedToken}}, nil
+	optional, err := token.NewOptionalToken(innerTok.ParsedToken, dec)
+	if err != nil {
+		return parseResult{ts, nil}, err
+	}
+	return parseResult{Remaining: remaining, ParsedToken: optional}, nil
 }
 
 func parseOneof(ts parseSequence) (parseResult, error) {
@@ -279,7 +387,11 @@ func parseOneof(ts parseSequence) (parseResult, error) {
 		entries = append(entries, token.OneofListEntry{Weight: dec, Token: innerResult.ParsedToken})
 	}
 
-	return parseResult{Remaining: remaining, ParsedToken: token.OneofListToken{Entries: entries}}, nil
+	oneof, err := token.NewOneofListToken(entries)
+	if err != nil {
+		return parseResult{ts, nil}, err
+	}
+	return parseResult{Remaining: remaining, ParsedToken: oneof}, nil
 }
 
 func tokenize(ts parseSequence) (parseResult, error) {
@@ -377,6 +489,9 @@ func readString(ts parseSequence) (string, parseSequence, error) {
 	return str, ts, nil
 }
 
+// readDecimal reads a weight or odds, such as 0.35 or 35%. A % followed by a
+// digit starts an ordinal instead, so 35 %12 is the weight 35 and then an
+// ordinal, while 35%%12 is the weight 0.35 and then an ordinal.
 func readDecimal(ts parseSequence) (float64, parseSequence, error) {
 	decPart := ""
 
@@ -393,7 +508,14 @@ func readDecimal(ts parseSequence) (float64, parseSequence, error) {
 		return 0, ts, fmt.Errorf("Expected decimal part")
 	}
 	decValue, err := strconv.ParseFloat(decPart, 64)
-	return decValue, ts, err
+	if err != nil {
+		return 0, ts, err
+	}
+	if len(ts) > 0 && ts[0].Equals(character{'%'}) && (len(ts) == 1 || !ts[1].IsDigit()) {
+		decValue /= 100
+		ts = ts[1:]
+	}
+	return decValue, ts, nil
 }
 
 func readDecimalTokenPair(ts parseSequence) (float64, parseResult, error) {
@@ -407,3 +529,25 @@ func readDecimalTokenPair(ts parseSequence) (float64, parseResult, error) {
 	}
 	return decValue, innerParsed, nil
 }
+
+// Reformat parses a template and writes it back out with token.Format. It
+// returns an error if the formatted template doesn't parse back to the same
+// token tree.
+func Reformat(contents string, width int) (string, error) {
+	tok, err := ParseFrom(contents)
+	if err != nil {
+		return "", err
+	}
+	formatted, err := token.Format(tok, width)
+	if err != nil {
+		return "", err
+	}
+	reparsed, err := ParseFrom(formatted)
+	if err != nil {
+		return "", fmt.Errorf("formatted template doesn't parse: %w", err)
+	}
+	if !reflect.DeepEqual(tok, reparsed) {
+		return "", fmt.Errorf("formatted template parses to %v, expected %v", reparsed, tok)
+	}
+	return formatted, nil
+}
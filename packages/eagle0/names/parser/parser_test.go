@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"errors"
 	"github.com/google/go-cmp/cmp"
 	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -174,6 +177,14 @@ func TestParseOrdinal(t *testing.T) {
 	}
 }
 
+func TestParseOrdinal_rejectsMaxBelowTwo(t *testing.T) {
+	for _, template := range []string{"%0", "%1", "$name %01"} {
+		if _, err := ParseFrom(template); err == nil {
+			t.Errorf("%s: expected an error", template)
+		}
+	}
+}
+
 func TestParseSubstitution(t *testing.T) {
 	formatString := "@FOO"
 
@@ -246,3 +257,199 @@ func TestParseFrom(t *testing.T) {
 		t.Errorf("Expected token to be %v, got '%v'", expected, tok)
 	}
 }
+
+func TestParseFrom_ignoresLineBreaks(t *testing.T) {
+	tok, err := ParseFrom("[0.5 $name,\r\n 0.5 $surname]\n")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	expected := token.OneofListToken{
+		Entries: []token.OneofListEntry{
+			{Token: token.ListSelectionToken{ChoiceListName: "name", Filtered: true}, Weight: 0.5},
+			{Token: token.ListSelectionToken{ChoiceListName: "surname", Filtered: true}, Weight: 0.5},
+		},
+	}
+	if !cmp.Equal(tok, expected) {
+		t.Errorf("Expected token to be %v, got '%v'", expected, tok)
+	}
+}
+
+func TestParseFrom_roundTripsThroughJSON(t *testing.T) {
+	contents, err := os.ReadFile("../nameConstruction.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := ParseFrom(string(contents))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := token.Marshal(tok)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	fromJSON, err := token.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if diff := cmp.Diff(tok, fromJSON); diff != "" {
+		t.Errorf("Expected the template to round-trip (-want +got):\n%s", diff)
+	}
+}
+
+func TestReformat_nameConstruction(t *testing.T) {
+	contents, err := os.ReadFile("../nameConstruction.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatted, err := Reformat(string(contents), 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(formatted, "\n"), "\n") {
+		if len([]rune(line)) > token.DefaultWidth {
+			t.Errorf("Expected lines of at most %d characters, got %q", token.DefaultWidth, line)
+		}
+	}
+	again, err := Reformat(formatted, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if again != formatted {
+		t.Errorf("Expected formatting to be stable, got:\n%s\nthen:\n%s", formatted, again)
+	}
+}
+
+func TestParseFrom_commentsAndWhitespace(t *testing.T) {
+	tok, err := ParseFrom("// the weights favour first names\r\n[0.75 $name, // most of the time\n\t0.25 \"a // b\"] // done")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := token.OneofListToken{
+		Entries: []token.OneofListEntry{
+			{Token: token.ListSelectionToken{ChoiceListName: "name", Filtered: true}, Weight: 0.75},
+			{Token: token.LiteralToken{Literal: "a // b"}, Weight: 0.25},
+		},
+	}
+	if !cmp.Equal(tok, expected) {
+		t.Errorf("Expected token to be %v, got '%v'", expected, tok)
+	}
+}
+
+func TestParseLiteral_escapes(t *testing.T) {
+	tests := map[string]string{
+		`"say \"hi\""`:         `say "hi"`,
+		`"back\\slash"`:        `back\slash`,
+		`"two\nlines"`:         "two\nlines",
+		`"\u{1F600} \u{e9}"`:   "\U0001F600 é",
+		"`raw \\n \"quoted\"`": `raw \n "quoted"`,
+		"`two\r\nlines`":       "two\nlines",
+	}
+	for formatString, expected := range tests {
+		remaining, tok, err := parseLiteral(formatString + " $rest")
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", formatString, err)
+			continue
+		}
+		if remaining != " $rest" {
+			t.Errorf("%s: expected remaining string to be ' $rest', got '%s'", formatString, remaining)
+		}
+		if tok.Literal != expected {
+			t.Errorf("%s: expected token to be %q, got %q", formatString, expected, tok.Literal)
+		}
+	}
+}
+
+func TestParseFrom_syntaxErrors(t *testing.T) {
+	tests := map[string]SyntaxError{
+		"$name \"unclosed":            {Line: 1, Column: 7, Msg: "Missing closing \" in literal"},
+		"$name\n  \"bad \\q escape\"": {Line: 2, Column: 8},
+		"$name\n\"é\\u{110000}\"":     {Line: 2, Column: 3},
+		"// comment\n\"line\nbreak\"": {Line: 2, Column: 6},
+		"$name `unclosed":             {Line: 1, Column: 7, Msg: "Missing closing ` in literal"},
+	}
+	for template, expected := range tests {
+		_, err := ParseFrom(template)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected a SyntaxError, got %v", template, err)
+			continue
+		}
+		if syntaxErr.Line != expected.Line || syntaxErr.Column != expected.Column {
+			t.Errorf("%q: expected an error at %d:%d, got %v", template, expected.Line, expected.Column, err)
+		}
+		if expected.Msg != "" && syntaxErr.Msg != expected.Msg {
+			t.Errorf("%q: expected %q, got %q", template, expected.Msg, syntaxErr.Msg)
+		}
+	}
+}
+
+func TestParseFrom_rejectsTopLevelComma(t *testing.T) {
+	if _, err := ParseFrom("$name, $surname"); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func TestReformat_escapedLiterals(t *testing.T) {
+	formatted, err := Reformat("// names\n`The \"Great\"` \"\\\\\" `multi\nline`", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "\"The \\\"Great\\\"\" \"\\\\\" \"multi\\nline\"\n"
+	if formatted != expected {
+		t.Errorf("Expected %q, got %q", expected, formatted)
+	}
+}
+
+func TestReadDecimal_percent(t *testing.T) {
+	tests := map[string]float64{
+		"35% $name": 0.35,
+		"7.5%":      0.075,
+		"35 %12":    35,
+		"35%%12":    0.35,
+	}
+	for formatString, expected := range tests {
+		ts, err := parseNext(formatString, parseSequence{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, _, err := readDecimal(ts)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", formatString, err)
+		}
+		if dec != expected {
+			t.Errorf("%s: expected %v, got %v", formatString, expected, dec)
+		}
+	}
+
+	tok, err := ParseFrom(`[75% $name, 25% %12]`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := token.OneofListToken{
+		Entries: []token.OneofListEntry{
+			{Token: token.ListSelectionToken{ChoiceListName: "name", Filtered: true}, Weight: 0.75},
+			{Token: token.OrdinalSelectionToken{Max: 12}, Weight: 0.25},
+		},
+	}
+	if !cmp.Equal(tok, expected) {
+		t.Errorf("Expected token to be %v, got '%v'", expected, tok)
+	}
+}
+
+func TestParseFrom_rejectsInvalidWeights(t *testing.T) {
+	for _, template := range []string{
+		`{1.5 "a"}`,
+		`{150% "a"}`,
+		`[0 "a", 0 "b"]`,
+		`[]`,
+		`[0.5 "a", 1.2.3 "b"]`,
+	} {
+		_, err := ParseFrom(template)
+		if err == nil {
+			t.Errorf("%s: expected an error", template)
+		}
+	}
+	if _, err := ParseFrom(`[0 "a", 1 "b"]`); err != nil {
+		t.Errorf("Expected zero weights to be allowed alongside a positive one, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Package release describes how published word lists and templates are laid
+// out in the bucket. Every published pair of files is stored under an
+// immutable version ID derived from its contents, and a pointer object names
+// the version currently being served.
+package release
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+const NamesTsv = "names.tsv"
+const NameConstruction = "nameConstruction.txt"
+
+// CurrentKey is the key of the object holding the current version ID.
+const CurrentKey = "current"
+
+const versionsPrefix = "versions/"
+
+var idPattern = regexp.MustCompile("^[0-9a-f]{16}$")
+
+// ID returns the version ID of a word list and template pair. The same
+// contents always produce the same ID.
+func ID(namesTsv []byte, nameConstruction []byte) string {
+	tsvSum := sha256.Sum256(namesTsv)
+	templateSum := sha256.Sum256(nameConstruction)
+	h := sha256.New()
+	h.Write(tsvSum[:])
+	h.Write(templateSum[:])
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// ValidID reports whether id has the form of a version ID.
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Key returns the key of the named file within a version.
+func Key(id string, file string) string {
+	return versionsPrefix + id + "/" + file
+}
+
+// ParsePointer extracts the version ID from the contents of the pointer object.
+func ParsePointer(contents []byte) (string, bool) {
+	id := strings.TrimSpace(string(contents))
+	return id, ValidID(id)
+}
+
+// HistoryKey is the key of the object listing published releases, newest
+// first. Rolling back moves the pointer to the first release after the current
+// one that hasn't been abandoned.
+const HistoryKey = "history"
+
+// abandonedMark follows the ID of an abandoned release in the history.
+const abandonedMark = "abandoned"
+
+// HistoryEntry is a release listed in the history. An abandoned release was
+// rolled back from: it stays listed, and so kept, until it ages out of the
+// history, but is never rolled back to.
+type HistoryEntry struct {
+	ID        string
+	Abandoned bool
+}
+
+// ParseHistory extracts the releases from the contents of the history
+// object, skipping anything that isn't an entry.
+func ParseHistory(contents []byte) []HistoryEntry {
+	entries := []HistoryEntry{}
+	for _, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !ValidID(fields[0]) {
+			continue
+		}
+		switch {
+		case len(fields) == 1:
+			entries = append(entries, HistoryEntry{ID: fields[0]})
+		case len(fields) == 2 && fields[1] == abandonedMark:
+			entries = append(entries, HistoryEntry{ID: fields[0], Abandoned: true})
+		}
+	}
+	return entries
+}
+
+// FormatHistory returns the contents of a history object listing entries.
+func FormatHistory(entries []HistoryEntry) []byte {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.ID)
+		if entry.Abandoned {
+			b.WriteString(" " + abandonedMark)
+		}
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
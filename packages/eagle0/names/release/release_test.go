@@ -0,0 +1,53 @@
+package release
+
+import "testing"
+
+func TestID_isStableAndContentAddressed(t *testing.T) {
+	id := ID([]byte("name\r\nAda"), []byte("$name"))
+	if !ValidID(id) {
+		t.Errorf("Expected a valid ID, got '%s'", id)
+	}
+	if again := ID([]byte("name\r\nAda"), []byte("$name")); again != id {
+		t.Errorf("Expected the same ID for the same contents, got '%s' and '%s'", id, again)
+	}
+	if other := ID([]byte("name\r\nBob"), []byte("$name")); other == id {
+		t.Errorf("Expected a different ID for different word lists")
+	}
+	if other := ID([]byte("name\r\nAda"), []byte("-$name+")); other == id {
+		t.Errorf("Expected a different ID for different templates")
+	}
+}
+
+func TestID_doesNotConfuseFileBoundaries(t *testing.T) {
+	if ID([]byte("ab"), []byte("c")) == ID([]byte("a"), []byte("bc")) {
+		t.Errorf("Expected the split between files to affect the ID")
+	}
+}
+
+func TestKey(t *testing.T) {
+	key := Key("0123456789abcdef", NamesTsv)
+	if key != "versions/0123456789abcdef/names.tsv" {
+		t.Errorf("Unexpected key '%s'", key)
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	id, ok := ParsePointer([]byte("0123456789abcdef\n"))
+	if !ok || id != "0123456789abcdef" {
+		t.Errorf("Expected '0123456789abcdef', got '%s' (%v)", id, ok)
+	}
+	if _, ok := ParsePointer([]byte("../../etc/passwd")); ok {
+		t.Errorf("Expected an invalid pointer to be rejected")
+	}
+}
+
+func TestHistory_roundTrip(t *testing.T) {
+	entries := []HistoryEntry{{ID: "0123456789abcdef"}, {ID: "fedcba9876543210", Abandoned: true}}
+	parsed := ParseHistory(FormatHistory(entries))
+	if len(parsed) != 2 || parsed[0] != entries[0] || parsed[1] != entries[1] {
+		t.Errorf("Expected %v, got %v", entries, parsed)
+	}
+	if parsed := ParseHistory([]byte("\nnot an id\n0123456789abcdef\r\nfedcba9876543210 unknown\n")); len(parsed) != 1 {
+		t.Errorf("Expected invalid lines to be skipped, got %v", parsed)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/accept"
+	"strings"
+)
+
+// output is everything a renderer may draw on: the generated names and the
+// options that produced them.
+type output struct {
+	Names   []NameResponse
+	Options options
+}
+
+type renderer struct {
+	ContentType string
+	Render      func(out output) (string, error)
+}
+
+const ndjsonType = "application/x-ndjson"
+
+// renderers lists the supported output formats in order of preference. The
+// first entry is used when the client does not express a preference.
+var renderers = []renderer{
+	{ContentType: "text/html", Render: renderHtml},
+	{ContentType: "application/json", Render: renderJson},
+	{ContentType: "text/plain", Render: renderText},
+	{ContentType: "text/csv", Render: renderCsv},
+	{ContentType: ndjsonType, Render: renderNdjson},
+	{ContentType: "application/xml", Render: renderXml},
+}
+
+func contentTypes() []string {
+	types := make([]string, len(renderers))
+	for i, r := range renderers {
+		types[i] = r.ContentType
+	}
+	return types
+}
+
+func negotiateRenderer(acceptHeader string) (renderer, bool) {
+	contentType, ok := accept.Negotiate(acceptHeader, contentTypes())
+	if !ok {
+		return renderer{}, false
+	}
+	for _, r := range renderers {
+		if r.ContentType == contentType {
+			return r, true
+		}
+	}
+	return renderer{}, false
+}
+
+func (r renderer) respond(out output) Response {
+	body, err := r.Render(out)
+	if err != nil {
+		fmt.Println("Error rendering", r.ContentType, ":", err)
+		return errorResponse(r.ContentType, err)
+	}
+	return Response{
+		Body:       body,
+		StatusCode: "200",
+		Headers: ResponseHeaders{
+			ContentType: r.ContentType,
+		},
+	}
+}
+
+func notAcceptable() Response {
+	return Response{
+		Body:       "Not acceptable. Available types: " + strings.Join(contentTypes(), ", ") + "\n",
+		StatusCode: "406",
+		Headers: ResponseHeaders{
+			ContentType: "text/plain",
+		},
+	}
+}
+
+type jsonBody struct {
+	Names []NameResponse `json:"names"`
+}
+
+func renderJson(out output) (string, error) {
+	bodyObj, err := json.Marshal(jsonBody{Names: out.Names})
+	if err != nil {
+		return "", err
+	}
+	return string(bodyObj), nil
+}
+
+// textErrorPrefix starts the line written in place of a name that failed, so
+// that a text response has one line per request.
+const textErrorPrefix = "error: "
+
+func renderText(out output) (string, error) {
+	var sb strings.Builder
+	for _, nameResponse := range out.Names {
+		if nameResponse.Error != nil {
+			sb.WriteString(textErrorPrefix)
+			sb.WriteString(strings.ReplaceAll(nameResponse.Error.message(), "\n", " "))
+		} else {
+			sb.WriteString(nameResponse.Name)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func renderCsv(out output) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"id", "name", "version", "error"}); err != nil {
+		return "", err
+	}
+	for _, nameResponse := range out.Names {
+		errorDetail := ""
+		if nameResponse.Error != nil {
+			errorDetail = nameResponse.Error.message()
+		}
+		if err := w.Write([]string{nameResponse.Id, nameResponse.Name, nameResponse.Version, errorDetail}); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func renderNdjson(out output) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, nameResponse := range out.Names {
+		if err := enc.Encode(nameResponse); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+type xmlName struct {
+	Id      string `xml:"id,attr"`
+	Version string `xml:"version,attr,omitempty"`
+	Error   string `xml:"error,attr,omitempty"`
+	Name    string `xml:",chardata"`
+}
+
+type xmlBody struct {
+	XMLName xml.Name  `xml:"names"`
+	Names   []xmlName `xml:"name"`
+}
+
+func renderXml(out output) (string, error) {
+	body := xmlBody{Names: make([]xmlName, len(out.Names))}
+	for i, nameResponse := range out.Names {
+		body.Names[i] = xmlName{Id: nameResponse.Id, Name: nameResponse.Name, Version: nameResponse.Version}
+		if nameResponse.Error != nil {
+			body.Names[i].Error = nameResponse.Error.message()
+		}
+	}
+	bodyObj, err := xml.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(bodyObj), nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/namegen"
+	"github.com/nolen777/name-generator/packages/eagle0/names/release"
+	"github.com/nolen777/name-generator/packages/eagle0/names/spaces_fetcher"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The unversioned files published before releases existed. They are still
+// served when the bucket has no release pointer.
+const namesTsvPath = release.NamesTsv
+const nameConstructionPath = release.NameConstruction
+
+// maxPinned is how many snapshots of pinned, non-current versions are kept.
+const maxPinned = 8
+
+var errUnknownVersion = errors.New("unknown data version")
+
+// errUnpinnableVersion is returned when pinning a version of the unversioned
+// files, which are overwritten in place and so can't be served once replaced.
+var errUnpinnableVersion = errors.New("data version is not a release and cannot be pinned")
+
+// legacyVersionPattern matches the versions storeVersion gives the unversioned
+// files.
+var legacyVersionPattern = regexp.MustCompile("^[0-9a-f]{12}$")
+
+// reloadInterval is how long a snapshot is served before the store is checked
+// for newer data.
+const reloadInterval = time.Minute
+
+// dataStore is where the word lists and the name template are published.
+type dataStore interface {
+	GetFile(path string) ([]byte, error)
+	Stat(path string) (spaces_fetcher.ObjectInfo, error)
+}
+
+type spacesStore struct{}
+
+func (spacesStore) GetFile(path string) ([]byte, error) {
+	return spaces_fetcher.GetFile(path)
+}
+
+func (spacesStore) Stat(path string) (spaces_fetcher.ObjectInfo, error) {
+	return spaces_fetcher.Stat(path)
+}
+
+// snapshot is an immutable generator built from one version of the data.
+type snapshot struct {
+	Version string
+	*namegen.Generator
+}
+
+// location identifies one version of the data and where its files live.
+type location struct {
+	Version              string
+	NamesTsvPath         string
+	NameConstructionPath string
+	// Release is set for content-addressed releases, whose contents are
+	// checked against their version ID when loaded.
+	Release bool
+}
+
+func releaseLocation(id string) location {
+	return location{
+		Version:              id,
+		NamesTsvPath:         release.Key(id, release.NamesTsv),
+		NameConstructionPath: release.Key(id, release.NameConstruction),
+		Release:              true,
+	}
+}
+
+// currentLocation follows the release pointer, falling back to the unversioned
+// files if nothing has been released yet.
+func currentLocation(store dataStore) (location, error) {
+	pointer, err := store.GetFile(release.CurrentKey)
+	if err == nil {
+		id, ok := release.ParsePointer(pointer)
+		if !ok {
+			return location{}, fmt.Errorf("invalid release pointer %q", pointer)
+		}
+		return releaseLocation(id), nil
+	}
+	if !errors.Is(err, spaces_fetcher.ErrNotFound) {
+		return location{}, err
+	}
+
+	version, err := storeVersion(store)
+	if err != nil {
+		return location{}, err
+	}
+	return location{
+		Version:              version,
+		NamesTsvPath:         namesTsvPath,
+		NameConstructionPath: nameConstructionPath,
+	}, nil
+}
+
+// reloader serves the current snapshot and swaps in a new one when the data
+// in the store changes. Function instances are frozen between invocations, so
+// rather than running a timer, a request that finds the snapshot older than
+// the interval starts a background check and is served the existing snapshot.
+type reloader struct {
+	store    dataStore
+	interval time.Duration
+
+	current   atomic.Pointer[snapshot]
+	lastCheck atomic.Int64
+	checking  atomic.Bool
+	loadMu    sync.Mutex
+
+	pinnedMu    sync.Mutex
+	pinned      map[string]*snapshot
+	pinnedOrder []string
+}
+
+var data = newReloader(spacesStore{}, reloadInterval)
+
+func newReloader(store dataStore, interval time.Duration) *reloader {
+	return &reloader{store: store, interval: interval, pinned: map[string]*snapshot{}}
+}
+
+// Snapshot returns the current snapshot, loading it synchronously if nothing
+// has been loaded yet.
+func (r *reloader) Snapshot() (*snapshot, error) {
+	snap := r.current.Load()
+	if snap == nil {
+		r.loadMu.Lock()
+		defer r.loadMu.Unlock()
+		if snap = r.current.Load(); snap != nil {
+			return snap, nil
+		}
+		return r.reload()
+	}
+
+	if time.Since(time.Unix(0, r.lastCheck.Load())) >= r.interval && r.checking.CompareAndSwap(false, true) {
+		go func() {
+			defer r.checking.Store(false)
+			r.loadMu.Lock()
+			defer r.loadMu.Unlock()
+			if _, err := r.reload(); err != nil {
+				fmt.Println("Error reloading data: ", err)
+			}
+		}()
+	}
+	return snap, nil
+}
+
+// reload rebuilds the snapshot if the store holds a different version than the
+// one currently served. The caller must hold loadMu.
+func (r *reloader) reload() (*snapshot, error) {
+	r.lastCheck.Store(time.Now().UnixNano())
+
+	loc, err := currentLocation(r.store)
+	if err != nil {
+		return nil, err
+	}
+	if current := r.current.Load(); current != nil && current.Version == loc.Version {
+		return current, nil
+	}
+
+	snap, err := loadSnapshot(r.store, loc)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Loaded data version", loc.Version)
+	r.current.Store(snap)
+	return snap, nil
+}
+
+// Pinned returns the snapshot for a specific released version, which need not
+// be the current one. It never loads the current data: the current snapshot
+// is only used if it is already loaded and is the version asked for.
+func (r *reloader) Pinned(version string) (*snapshot, error) {
+	if current := r.current.Load(); current != nil && current.Version == version {
+		return current, nil
+	}
+	if legacyVersionPattern.MatchString(version) {
+		return nil, fmt.Errorf("%w: %s", errUnpinnableVersion, version)
+	}
+	if !release.ValidID(version) {
+		return nil, fmt.Errorf("%w: %s", errUnknownVersion, version)
+	}
+
+	r.pinnedMu.Lock()
+	defer r.pinnedMu.Unlock()
+	if snap, ok := r.pinned[version]; ok {
+		return snap, nil
+	}
+
+	snap, err := loadSnapshot(r.store, releaseLocation(version))
+	if errors.Is(err, spaces_fetcher.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", errUnknownVersion, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(r.pinnedOrder) >= maxPinned {
+		delete(r.pinned, r.pinnedOrder[0])
+		r.pinnedOrder = r.pinnedOrder[1:]
+	}
+	r.pinned[version] = snap
+	r.pinnedOrder = append(r.pinnedOrder, version)
+	return snap, nil
+}
+
+// storeVersion identifies the unversioned files by their ETags, falling back
+// to their modification times.
+func storeVersion(store dataStore) (string, error) {
+	h := sha256.New()
+	for _, path := range []string{namesTsvPath, nameConstructionPath} {
+		info, err := store.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		tag := info.ETag
+		if tag == "" {
+			tag = info.LastModified.UTC().Format(time.RFC3339Nano)
+		}
+		fmt.Fprintf(h, "%s=%s\n", path, tag)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+func loadSnapshot(store dataStore, loc location) (*snapshot, error) {
+	var wg sync.WaitGroup
+	var namesTsv, rawStringConstructionToken []byte
+	var tsvErr, tokErr error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		namesTsv, tsvErr = store.GetFile(loc.NamesTsvPath)
+	}()
+	go func() {
+		defer wg.Done()
+		rawStringConstructionToken, tokErr = store.GetFile(loc.NameConstructionPath)
+	}()
+
+	wg.Wait()
+	if tsvErr != nil {
+		return nil, tsvErr
+	}
+	if tokErr != nil {
+		return nil, tokErr
+	}
+	if loc.Release && release.ID(namesTsv, rawStringConstructionToken) != loc.Version {
+		return nil, fmt.Errorf("contents of release %s do not match its version", loc.Version)
+	}
+	generator, err := namegen.Load(namesTsv, rawStringConstructionToken)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{Version: loc.Version, Generator: generator}, nil
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"github.com/nolen777/name-generator/packages/eagle0/names/release"
+	"github.com/nolen777/name-generator/packages/eagle0/names/spaces_fetcher"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu    sync.Mutex
+	files map[string]string
+	gets  map[string]int
+}
+
+func newMemStore(files map[string]string) *memStore {
+	return &memStore{files: files, gets: map[string]int{}}
+}
+
+func (s *memStore) GetFile(path string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets[path]++
+	contents, ok := s.files[path]
+	if !ok {
+		return nil, spaces_fetcher.ErrNotFound
+	}
+	return []byte(contents), nil
+}
+
+func (s *memStore) Stat(path string) (spaces_fetcher.ObjectInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contents, ok := s.files[path]
+	if !ok {
+		return spaces_fetcher.ObjectInfo{}, spaces_fetcher.ErrNotFound
+	}
+	sum := md5.Sum([]byte(contents))
+	return spaces_fetcher.ObjectInfo{ETag: hex.EncodeToString(sum[:])}, nil
+}
+
+func (s *memStore) put(path string, contents string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = contents
+}
+
+func (s *memStore) getCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets[path]
+}
+
+// useStore serves data from store for the rest of the test.
+func useStore(t *testing.T, store dataStore) {
+	saved := data
+	data = newReloader(store, time.Hour)
+	t.Cleanup(func() { data = saved })
+}
+
+func TestReloader_loadsOnFirstUse(t *testing.T) {
+	store := newMemStore(map[string]string{
+		namesTsvPath:         "name@female\tname@male\r\nAda\tBob",
+		nameConstructionPath: "$name\r\n",
+	})
+	r := newReloader(store, time.Hour)
+
+	snap, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	name, err := snap.Template().Next(fixedRandomSource{}, snap.For("female"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if name != "Ada" {
+		t.Errorf("Expected 'Ada', got '%s'", name)
+	}
+}
+
+func TestReloader_keepsSnapshotWhenUnchanged(t *testing.T) {
+	store := newMemStore(map[string]string{
+		namesTsvPath:         "name\r\nAda",
+		nameConstructionPath: "$name",
+	})
+	r := newReloader(store, 0)
+
+	first, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	gets := store.getCount(namesTsvPath)
+
+	r.loadMu.Lock()
+	second, err := r.reload()
+	r.loadMu.Unlock()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first != second {
+		t.Errorf("Expected the same snapshot when the store is unchanged")
+	}
+	if store.getCount(namesTsvPath) != gets {
+		t.Errorf("Expected no downloads when the store is unchanged")
+	}
+}
+
+func TestReloader_swapsInNewData(t *testing.T) {
+	store := newMemStore(map[string]string{
+		namesTsvPath:         "name\r\nAda",
+		nameConstructionPath: "$name",
+	})
+	r := newReloader(store, 0)
+
+	first, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store.put(namesTsvPath, "name\r\nBob")
+
+	// The stale snapshot is served while the new one is built in the background.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		snap, err := r.Snapshot()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if snap != first {
+			if snap.Version == first.Version {
+				t.Errorf("Expected a new version, got '%s' again", snap.Version)
+			}
+			name, _ := snap.Template().Next(fixedRandomSource{}, snap.For(""))
+			if name != "Bob" {
+				t.Errorf("Expected 'Bob', got '%s'", name)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for reload")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReloader_keepsServingAfterFailedReload(t *testing.T) {
+	store := newMemStore(map[string]string{
+		namesTsvPath:         "name\r\nAda",
+		nameConstructionPath: "$name",
+	})
+	r := newReloader(store, 0)
+
+	first, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store.put(nameConstructionPath, "[0.5 $name")
+
+	r.loadMu.Lock()
+	_, err = r.reload()
+	r.loadMu.Unlock()
+	if err == nil {
+		t.Errorf("Expected an error for a bad template")
+	}
+	if snap, _ := r.Snapshot(); snap != first {
+		t.Errorf("Expected the previous snapshot to still be served")
+	}
+}
+
+func TestReloader_initialLoadError(t *testing.T) {
+	r := newReloader(newMemStore(map[string]string{}), time.Hour)
+	if _, err := r.Snapshot(); err == nil {
+		t.Errorf("Expected an error when the store is empty")
+	}
+}
+
+// releaseStore returns a store holding the given releases, with the last one
+// current, and the IDs of the releases in order.
+func releaseStore(releases ...[2]string) (*memStore, []string) {
+	files := map[string]string{}
+	ids := []string{}
+	for _, r := range releases {
+		id := release.ID([]byte(r[0]), []byte(r[1]))
+		files[release.Key(id, release.NamesTsv)] = r[0]
+		files[release.Key(id, release.NameConstruction)] = r[1]
+		files[release.CurrentKey] = id + "\n"
+		ids = append(ids, id)
+	}
+	return newMemStore(files), ids
+}
+
+func TestReloader_followsReleasePointer(t *testing.T) {
+	store, ids := releaseStore([2]string{"name\r\nAda", "$name"})
+	store.put(namesTsvPath, "name\r\nLegacy")
+	store.put(nameConstructionPath, "$name")
+	r := newReloader(store, time.Hour)
+
+	snap, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if snap.Version != ids[0] {
+		t.Errorf("Expected version '%s', got '%s'", ids[0], snap.Version)
+	}
+	name, _ := snap.Template().Next(fixedRandomSource{}, snap.For(""))
+	if name != "Ada" {
+		t.Errorf("Expected 'Ada', got '%s'", name)
+	}
+}
+
+func TestReloader_rejectsTamperedRelease(t *testing.T) {
+	store, ids := releaseStore([2]string{"name\r\nAda", "$name"})
+	store.put(release.Key(ids[0], release.NamesTsv), "name\r\nMallory")
+	r := newReloader(store, time.Hour)
+
+	if _, err := r.Snapshot(); err == nil {
+		t.Errorf("Expected an error for a release whose contents changed")
+	}
+}
+
+func TestReloader_pinnedVersion(t *testing.T) {
+	store, ids := releaseStore(
+		[2]string{"name\r\nAda", "$name"},
+		[2]string{"name\r\nBob", "$name"},
+	)
+	r := newReloader(store, time.Hour)
+
+	snap, err := r.Pinned(ids[0])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	name, _ := snap.Template().Next(fixedRandomSource{}, snap.For(""))
+	if name != "Ada" {
+		t.Errorf("Expected 'Ada' from the pinned version, got '%s'", name)
+	}
+	if r.current.Load() != nil {
+		t.Errorf("Expected pinning not to load the current data")
+	}
+
+	current, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if current.Version != ids[1] {
+		t.Errorf("Expected current version '%s', got '%s'", ids[1], current.Version)
+	}
+	if again, _ := r.Pinned(ids[0]); again != snap {
+		t.Errorf("Expected the pinned snapshot to be cached")
+	}
+}
+
+func TestReloader_unknownPinnedVersion(t *testing.T) {
+	store, _ := releaseStore([2]string{"name\r\nAda", "$name"})
+	r := newReloader(store, time.Hour)
+
+	for _, version := range []string{"0123456789abcdef", "../names.tsv"} {
+		if _, err := r.Pinned(version); !errors.Is(err, errUnknownVersion) {
+			t.Errorf("Expected errUnknownVersion for '%s', got %v", version, err)
+		}
+	}
+}
+
+func TestReloader_legacyPinnedVersion(t *testing.T) {
+	store := newMemStore(map[string]string{
+		namesTsvPath:         "name\r\nAda",
+		nameConstructionPath: "$name",
+	})
+	r := newReloader(store, time.Hour)
+
+	if _, err := r.Pinned("0123456789ab"); !errors.Is(err, errUnpinnableVersion) {
+		t.Errorf("Expected errUnpinnableVersion before loading, got %v", err)
+	}
+	if r.current.Load() != nil {
+		t.Errorf("Expected pinning not to load the current data")
+	}
+
+	current, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if snap, err := r.Pinned(current.Version); err != nil || snap != current {
+		t.Errorf("Expected the current unreleased version to be served while it is current, got %v", err)
+	}
+}
+
+type fixedRandomSource struct{}
+
+func (fixedRandomSource) Intn(n int) int   { return 0 }
+func (fixedRandomSource) Float64() float64 { return 0 }
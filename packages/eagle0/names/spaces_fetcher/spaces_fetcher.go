@@ -1,15 +1,21 @@
 package spaces_fetcher
 
 import (
+	"errors"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"io"
 	"os"
+	"time"
 )
 
 var bucketName = "eagle0-config"
+
+// ErrNotFound is returned when no object exists at the requested path.
+var ErrNotFound = errors.New("not found")
 var sharedClient *s3.S3 = createS3Client()
 
 func createS3Client() *s3.S3 {
@@ -42,9 +48,43 @@ func GetFile(path string) ([]byte, error) {
 
 	out, err := sharedClient.GetObject(&getObjInput)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	defer out.Body.Close()
 
 	return io.ReadAll(out.Body)
 }
+
+// ObjectInfo describes a stored object without its contents.
+type ObjectInfo struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// Stat returns the ETag and modification time of the object at path.
+func Stat(path string) (ObjectInfo, error) {
+	headObjInput := s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(path),
+	}
+
+	out, err := sharedClient.HeadObject(&headObjInput)
+	if err != nil {
+		return ObjectInfo{}, translateError(err)
+	}
+	return ObjectInfo{
+		ETag:         aws.StringValue(out.ETag),
+		LastModified: aws.TimeValue(out.LastModified),
+	}, nil
+}
+
+func translateError(err error) error {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		switch awsErr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return ErrNotFound
+		}
+	}
+	return err
+}
@@ -13,3 +13,20 @@ func TestGetFile(t *testing.T) {
 		t.Fatalf("Expected non-empty data, got empty")
 	}
 }
+
+func TestStat(t *testing.T) {
+	info, err := Stat("names.tsv")
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.ETag == "" {
+		t.Fatalf("Expected an ETag, got empty")
+	}
+}
+
+func TestGetFile_notFound(t *testing.T) {
+	_, err := GetFile("no/such/file.txt")
+	if err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
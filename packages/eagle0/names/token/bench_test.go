@@ -0,0 +1,75 @@
+package token_test
+
+import (
+	"context"
+	"github.com/nolen777/name-generator/packages/eagle0/names/loader"
+	"github.com/nolen777/name-generator/packages/eagle0/names/token"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func loadData(b *testing.B) loader.Data {
+	namesTsv, err := os.ReadFile("../names.tsv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	template, err := os.ReadFile("../nameConstruction.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, err := loader.Load(namesTsv, template)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkGenerate_tree(b *testing.B) {
+	data := loadData(b)
+	ctx := data.For("female")
+	rGen := rand.New(rand.NewSource(1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := token.Generate(context.Background(), data.Token, rGen, ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerate_compiled(b *testing.B) {
+	data := loadData(b)
+	ctx := data.For("female")
+	program, err := token.Compile(data.Token)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rGen := rand.New(rand.NewSource(1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := program.Generate(context.Background(), rGen, ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerate_compiledParallel(b *testing.B) {
+	data := loadData(b)
+	ctx := data.For("female")
+	program, err := token.Compile(data.Token)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		rGen := rand.New(rand.NewSource(1))
+		for pb.Next() {
+			if _, err := program.Generate(context.Background(), rGen, ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
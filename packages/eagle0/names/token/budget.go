@@ -0,0 +1,95 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrBudgetExceeded is returned when generating a string takes more steps,
+// nests more deeply or produces more output than its Budget allows.
+var ErrBudgetExceeded = errors.New("generation budget exceeded")
+
+// Budget limits the work done generating one string. A zero limit is
+// unlimited.
+type Budget struct {
+	// MaxSteps limits how many tokens are evaluated.
+	MaxSteps int
+	// MaxDepth limits how deeply tokens are nested.
+	MaxDepth int
+	// MaxOutput limits the length in bytes of any generated string.
+	MaxOutput int
+}
+
+// BudgetError reports which limit of a Budget was exceeded.
+type BudgetError struct {
+	Limit string
+	Max   int
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s: more than %d %s", ErrBudgetExceeded, e.Max, e.Limit)
+}
+
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// usage is the work done so far in one generation. It is shared by every copy
+// of the StringConstructionContext made while generating.
+type usage struct {
+	steps int
+	done  <-chan struct{}
+}
+
+// enter is called by every token before it does any work. It returns the
+// context to pass to nested tokens, or an error if the generation has been
+// cancelled or has run out of budget.
+func (ctx StringConstructionContext) enter() (StringConstructionContext, error) {
+	if ctx.usage == nil {
+		ctx.usage = &usage{}
+		if ctx.Context != nil {
+			ctx.usage.done = ctx.Context.Done()
+		}
+	}
+	select {
+	case <-ctx.usage.done:
+		return ctx, ctx.Context.Err()
+	default:
+	}
+
+	ctx.usage.steps++
+	if ctx.Budget.MaxSteps > 0 && ctx.usage.steps > ctx.Budget.MaxSteps {
+		return ctx, &BudgetError{Limit: "steps", Max: ctx.Budget.MaxSteps}
+	}
+	ctx.depth++
+	if ctx.Budget.MaxDepth > 0 && ctx.depth > ctx.Budget.MaxDepth {
+		return ctx, &BudgetError{Limit: "levels of nesting", Max: ctx.Budget.MaxDepth}
+	}
+	return ctx, nil
+}
+
+// checkOutput returns an error if n bytes of output is more than the budget
+// allows.
+func (ctx StringConstructionContext) checkOutput(n int) error {
+	if ctx.Budget.MaxOutput > 0 && n > ctx.Budget.MaxOutput {
+		return &BudgetError{Limit: "bytes of output", Max: ctx.Budget.MaxOutput}
+	}
+	return nil
+}
+
+// Generate produces a string from tok, stopping with the context's error once
+// c is done and with a BudgetError if the context's Budget is exceeded.
+func Generate(c context.Context, tok StringConstructionToken, rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
+	ctx.Context = c
+	ctx.usage = nil
+	ctx.depth = 0
+	result, err := ctx.next(tok, rand)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.checkOutput(len(result)); err != nil {
+		return "", err
+	}
+	return result, nil
+}
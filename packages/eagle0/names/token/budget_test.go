@@ -0,0 +1,84 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"strings"
+	"testing"
+)
+
+// nest wraps tok in depth sequences.
+func nest(tok StringConstructionToken, depth int) StringConstructionToken {
+	for i := 0; i < depth; i++ {
+		tok = SequenceToken{Tokens: []StringConstructionToken{tok}}
+	}
+	return tok
+}
+
+func TestGenerate_withinBudget(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		LiteralToken{Literal: "Sir "},
+		ListSelectionToken{ChoiceListName: "name"},
+	}}
+	ctx := StringConstructionContext{
+		Lexicon: lexicon.FromStrings(map[string][]string{"name": {"Ada"}}),
+		Budget:  Budget{MaxSteps: 3, MaxDepth: 2, MaxOutput: 7},
+	}
+
+	result, err := Generate(context.Background(), tok, fixedRandomSource{}, ctx)
+	if err != nil || result != "Sir Ada" {
+		t.Errorf("Expected 'Sir Ada', got '%s' (%v)", result, err)
+	}
+}
+
+func TestGenerate_budgetExceeded(t *testing.T) {
+	many := SequenceToken{}
+	for i := 0; i < 10; i++ {
+		many.Tokens = append(many.Tokens, LiteralToken{Literal: "ab"})
+	}
+
+	tests := map[string]struct {
+		tok    StringConstructionToken
+		budget Budget
+		limit  string
+	}{
+		"steps":  {many, Budget{MaxSteps: 5}, "steps"},
+		"depth":  {nest(LiteralToken{Literal: "a"}, 10), Budget{MaxDepth: 5}, "levels of nesting"},
+		"output": {many, Budget{MaxOutput: 15}, "bytes of output"},
+		"title":  {TitleCaseToken{Base: many}, Budget{MaxOutput: 15}, "bytes of output"},
+	}
+	for name, test := range tests {
+		_, err := Generate(context.Background(), test.tok, fixedRandomSource{}, StringConstructionContext{Budget: test.budget})
+		var budgetErr *BudgetError
+		if !errors.Is(err, ErrBudgetExceeded) || !errors.As(err, &budgetErr) || budgetErr.Limit != test.limit {
+			t.Errorf("%s: expected the %s budget to be exceeded, got %v", name, test.limit, err)
+		}
+	}
+}
+
+func TestGenerate_budgetIsPerGeneration(t *testing.T) {
+	ctx := StringConstructionContext{Budget: Budget{MaxSteps: 1}}
+	for i := 0; i < 3; i++ {
+		if _, err := Generate(context.Background(), LiteralToken{Literal: "a"}, nil, ctx); err != nil {
+			t.Fatalf("Expected generation %d to have its own budget, got %v", i, err)
+		}
+	}
+}
+
+func TestGenerate_cancelled(t *testing.T) {
+	c, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Generate(c, nest(LiteralToken{Literal: "a"}, 3), nil, StringConstructionContext{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBudgetError_message(t *testing.T) {
+	err := &BudgetError{Limit: "steps", Max: 5}
+	if !strings.Contains(err.Error(), "more than 5 steps") {
+		t.Errorf("Unexpected message '%s'", err.Error())
+	}
+}
@@ -0,0 +1,341 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"strconv"
+	"sync"
+)
+
+type opcode uint8
+
+const (
+	// opLiteral writes strings[arg].
+	opLiteral opcode = iota
+	// opSubstitution writes the substitution for the key strings[arg].
+	opSubstitution
+	// opList writes an entry picked from lists[arg].
+	opList
+	// opOrdinal writes an ordinal below arg.
+	opOrdinal
+	// opOptional jumps to jump unless a draw falls below odds[arg].
+	opOptional
+	// opChoose jumps to the start of an entry picked from tables[arg].
+	opChoose
+	// opJump jumps to jump.
+	opJump
+	// opTitle marks the start of output to be title cased.
+	opTitle
+	// opTitleEnd title cases the output since the matching opTitle.
+	opTitleEnd
+	// opToken writes the output of tokens[arg], for token types the
+	// compiler doesn't know.
+	opToken
+)
+
+type instruction struct {
+	op   opcode
+	arg  int
+	jump int
+	// depth is how deeply the instruction's token is nested, for checking
+	// Budget.MaxDepth.
+	depth int
+}
+
+type listRef struct {
+	name     string
+	filtered bool
+}
+
+// aliasTable picks an entry in proportion to its weight in constant time,
+// using Vose's alias method.
+type aliasTable struct {
+	prob  []float64
+	alias []int
+	// starts holds where each entry's instructions begin.
+	starts []int
+}
+
+func newAliasTable(weights []float64) aliasTable {
+	n := len(weights)
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	t := aliasTable{prob: make([]float64, n), alias: make([]int, n), starts: make([]int, n)}
+	scaled := make([]float64, n)
+	small, large := []int{}, []int{}
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		t.prob[s] = scaled[s]
+		t.alias[s] = l
+		scaled[l] -= 1 - scaled[s]
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// Whatever is left over has a share of 1, give or take rounding.
+	for _, i := range append(small, large...) {
+		t.prob[i] = 1
+		t.alias[i] = i
+	}
+	return t
+}
+
+// pick chooses an entry using a single draw r in [0, 1): its integer part
+// scaled by the number of entries picks a column, and its fraction decides
+// between the column and its alias.
+func (t aliasTable) pick(r float64) int {
+	u := r * float64(len(t.prob))
+	i := int(u)
+	if i >= len(t.prob) {
+		i = len(t.prob) - 1
+	}
+	if u-float64(i) < t.prob[i] {
+		return i
+	}
+	return t.alias[i]
+}
+
+// Program is a token tree compiled for generating many strings quickly. The
+// tree is flattened into a list of instructions, weighted choices use alias
+// tables, and output is written to a reused buffer.
+//
+// A Program generates the same kinds of strings as the tree it was compiled
+// from, but picks oneof entries differently, so a seeded random source gives
+// different strings than the tree does. Programs are safe for concurrent use.
+type Program struct {
+	instructions []instruction
+	strings      []string
+	lists        []listRef
+	odds         []float64
+	tables       []aliasTable
+	tokens       []StringConstructionToken
+}
+
+// Compile turns a token tree into a Program, checking its weights and odds.
+func Compile(tok StringConstructionToken) (*Program, error) {
+	p := &Program{}
+	if err := p.compile(tok, 1); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Program) emit(ins instruction) int {
+	p.instructions = append(p.instructions, ins)
+	return len(p.instructions) - 1
+}
+
+func (p *Program) compile(tok StringConstructionToken, depth int) error {
+	switch tok := tok.(type) {
+	case LiteralToken:
+		p.strings = append(p.strings, tok.Literal)
+		p.emit(instruction{op: opLiteral, arg: len(p.strings) - 1, depth: depth})
+	case SubstitutionToken:
+		p.strings = append(p.strings, tok.Key)
+		p.emit(instruction{op: opSubstitution, arg: len(p.strings) - 1, depth: depth})
+	case ListSelectionToken:
+		p.lists = append(p.lists, listRef{name: tok.ChoiceListName, filtered: tok.Filtered})
+		p.emit(instruction{op: opList, arg: len(p.lists) - 1, depth: depth})
+	case OrdinalSelectionToken:
+		if tok.Max < 2 {
+			return fmt.Errorf("ordinal max %d must be at least 2", tok.Max)
+		}
+		p.emit(instruction{op: opOrdinal, arg: tok.Max, depth: depth})
+	case SequenceToken:
+		for _, child := range tok.Tokens {
+			if err := p.compile(child, depth+1); err != nil {
+				return err
+			}
+		}
+	case OptionalToken:
+		if err := ValidateOdds(tok.Odds); err != nil {
+			return err
+		}
+		p.odds = append(p.odds, tok.Odds)
+		at := p.emit(instruction{op: opOptional, arg: len(p.odds) - 1, depth: depth})
+		if err := p.compile(tok.Token, depth+1); err != nil {
+			return err
+		}
+		p.instructions[at].jump = len(p.instructions)
+	case OneofListToken:
+		if err := ValidateWeights(tok.Entries); err != nil {
+			return err
+		}
+		weights := make([]float64, len(tok.Entries))
+		for i, entry := range tok.Entries {
+			weights[i] = entry.Weight
+		}
+		p.tables = append(p.tables, newAliasTable(weights))
+		table := len(p.tables) - 1
+		p.emit(instruction{op: opChoose, arg: table, depth: depth})
+
+		jumps := []int{}
+		for i, entry := range tok.Entries {
+			p.tables[table].starts[i] = len(p.instructions)
+			if err := p.compile(entry.Token, depth+1); err != nil {
+				return err
+			}
+			if i < len(tok.Entries)-1 {
+				jumps = append(jumps, p.emit(instruction{op: opJump, depth: depth}))
+			}
+		}
+		for _, at := range jumps {
+			p.instructions[at].jump = len(p.instructions)
+		}
+	case TitleCaseToken:
+		p.emit(instruction{op: opTitle, depth: depth})
+		if err := p.compile(tok.Base, depth+1); err != nil {
+			return err
+		}
+		p.emit(instruction{op: opTitleEnd, depth: depth})
+	case nil:
+		return fmt.Errorf("cannot compile a missing token")
+	default:
+		p.tokens = append(p.tokens, tok)
+		p.emit(instruction{op: opToken, arg: len(p.tokens) - 1, depth: depth})
+	}
+	return nil
+}
+
+// machine is the scratch space for one generation.
+type machine struct {
+	buf    []byte
+	titles []int
+	// scratch holds output being title cased.
+	scratch []byte
+}
+
+var machines = sync.Pool{New: func() any { return &machine{buf: make([]byte, 0, 256)} }}
+
+// cancelCheckInterval is how many instructions run between checks for
+// cancellation, after the check before the first.
+const cancelCheckInterval = 256
+
+// Generate produces a string, honouring the context's Budget and stopping
+// with the context's error once c is done. MaxSteps counts instructions run
+// rather than tokens evaluated.
+func (p *Program) Generate(c context.Context, rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
+	m := machines.Get().(*machine)
+	defer machines.Put(m)
+	m.buf = m.buf[:0]
+	m.titles = m.titles[:0]
+
+	var done <-chan struct{}
+	if c != nil {
+		done = c.Done()
+	}
+	budget := ctx.Budget
+	steps := 0
+
+	for pc := 0; pc < len(p.instructions); {
+		ins := p.instructions[pc]
+		pc++
+
+		steps++
+		if budget.MaxSteps > 0 && steps > budget.MaxSteps {
+			return "", &BudgetError{Limit: "steps", Max: budget.MaxSteps}
+		}
+		if budget.MaxDepth > 0 && ins.depth > budget.MaxDepth {
+			return "", &BudgetError{Limit: "levels of nesting", Max: budget.MaxDepth}
+		}
+		if done != nil && steps%cancelCheckInterval == 1 {
+			select {
+			case <-done:
+				return "", c.Err()
+			default:
+			}
+		}
+
+		switch ins.op {
+		case opLiteral:
+			m.buf = append(m.buf, p.strings[ins.arg]...)
+		case opSubstitution:
+			value, ok := ctx.LiteralSubstitutions[p.strings[ins.arg]]
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrMissingKey, p.strings[ins.arg])
+			}
+			m.buf = append(m.buf, value...)
+		case opList:
+			ref := p.lists[ins.arg]
+			if err := m.pickFrom(ref, rand, ctx); err != nil {
+				return "", err
+			}
+		case opOrdinal:
+			m.buf = appendOrdinal(m.buf, rand.Intn(ins.arg-1)+1)
+		case opOptional:
+			if !(rand.Float64() < p.odds[ins.arg]) {
+				pc = ins.jump
+			}
+		case opChoose:
+			table := p.tables[ins.arg]
+			pc = table.starts[table.pick(rand.Float64())]
+		case opJump:
+			pc = ins.jump
+		case opTitle:
+			m.titles = append(m.titles, len(m.buf))
+		case opTitleEnd:
+			start := m.titles[len(m.titles)-1]
+			m.titles = m.titles[:len(m.titles)-1]
+			m.scratch = append(m.scratch[:0], m.buf[start:]...)
+			m.buf = appendTitleCase(m.buf[:start], m.scratch)
+		case opToken:
+			result, err := p.tokens[ins.arg].Next(rand, ctx)
+			if err != nil {
+				return "", err
+			}
+			m.buf = append(m.buf, result...)
+		}
+
+		if budget.MaxOutput > 0 && len(m.buf) > budget.MaxOutput {
+			return "", &BudgetError{Limit: "bytes of output", Max: budget.MaxOutput}
+		}
+	}
+	return string(m.buf), nil
+}
+
+func (m *machine) pickFrom(ref listRef, rand TokenRandomSource, ctx StringConstructionContext) error {
+	filter := lexicon.Filter{}
+	if ref.filtered {
+		filter = ctx.Filter
+	}
+	view, ok := ctx.Lexicon.Select(ref.name, filter)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrMissingList, ref.name)
+	}
+	if view.Len() == 0 {
+		return fmt.Errorf("%w: %s", ErrEmptyList, ref.name)
+	}
+	m.buf = append(m.buf, view.Pick(rand).Text...)
+	return nil
+}
+
+// appendOrdinal writes value with its English ordinal suffix, such as 21st.
+func appendOrdinal(buf []byte, value int) []byte {
+	buf = strconv.AppendInt(buf, int64(value), 10)
+	if value%100 == 11 || value%100 == 12 || value%100 == 13 {
+		return append(buf, "th"...)
+	}
+	switch value % 10 {
+	case 1:
+		return append(buf, "st"...)
+	case 2:
+		return append(buf, "nd"...)
+	case 3:
+		return append(buf, "rd"...)
+	default:
+		return append(buf, "th"...)
+	}
+}
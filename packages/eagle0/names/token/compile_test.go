@@ -0,0 +1,198 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// aliasShares returns the chance of each entry being picked by t.
+func aliasShares(t aliasTable) []float64 {
+	n := float64(len(t.prob))
+	shares := make([]float64, len(t.prob))
+	for i := range t.prob {
+		shares[i] += t.prob[i] / n
+		shares[t.alias[i]] += (1 - t.prob[i]) / n
+	}
+	return shares
+}
+
+func TestAliasTable_matchesWeights(t *testing.T) {
+	for _, weights := range [][]float64{
+		{1},
+		{0.5, 0.3, 0.2},
+		{0, 1, 0, 3},
+		{0.1, 0.05, 0.1, 0.1, 0.1, 0.1, 0.1, 0.2, 0.2, 0.1, 0.1},
+	} {
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+		table := newAliasTable(weights)
+		for i, share := range aliasShares(table) {
+			if math.Abs(share-weights[i]/total) > 1e-9 {
+				t.Errorf("%v: expected entry %d to have share %v, got %v", weights, i, weights[i]/total, share)
+			}
+		}
+		for _, r := range []float64{0, 0.25, 0.5, 0.9999999999999999} {
+			if i := table.pick(r); weights[i] == 0 {
+				t.Errorf("%v: picked entry %d with no weight for %v", weights, i, r)
+			}
+		}
+	}
+}
+
+func TestProgram_matchesTreeWithoutChoices(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		TitleCaseToken{Base: SequenceToken{Tokens: []StringConstructionToken{
+			ListSelectionToken{ChoiceListName: "name", Filtered: true},
+			LiteralToken{Literal: " of the "},
+			SubstitutionToken{Key: "place"},
+		}}},
+		OptionalToken{Token: LiteralToken{Literal: ", the "}, Odds: 0.5},
+		OptionalToken{Token: OrdinalSelectionToken{Max: 30}, Odds: 0.5},
+	}}
+	ctx := StringConstructionContext{
+		Lexicon:              lexicon.FromStrings(map[string][]string{"name": {"ada", "bob", "cy"}}),
+		LiteralSubstitutions: map[string]string{"place": "north and south"},
+	}
+	program, err := Compile(tok)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tree, compiled := rand.New(rand.NewSource(7)), rand.New(rand.NewSource(7))
+	for i := 0; i < 100; i++ {
+		expected, err := Generate(context.Background(), tok, tree, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := program.Generate(context.Background(), compiled, ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != expected {
+			t.Fatalf("Expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestProgram_choosesEntries(t *testing.T) {
+	tok := OneofListToken{Entries: []OneofListEntry{
+		{Token: LiteralToken{Literal: "a"}, Weight: 0},
+		{Token: SequenceToken{Tokens: []StringConstructionToken{LiteralToken{Literal: "b"}, LiteralToken{Literal: "c"}}}, Weight: 1},
+		{Token: LiteralToken{Literal: "d"}, Weight: 1},
+	}}
+	program, err := Compile(tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]int{}
+	rGen := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		result, err := program.Generate(context.Background(), rGen, emptyContext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[result]++
+	}
+	if len(seen) != 2 || seen["bc"] < 400 || seen["d"] < 400 {
+		t.Errorf("Expected bc and d about equally often, got %v", seen)
+	}
+}
+
+func TestCompile_rejectsInvalidTrees(t *testing.T) {
+	for _, tok := range []StringConstructionToken{
+		OneofListToken{Entries: []OneofListEntry{{Token: LiteralToken{Literal: "a"}, Weight: 0}}},
+		OptionalToken{Token: LiteralToken{Literal: "a"}, Odds: 1.5},
+		OrdinalSelectionToken{Max: 1},
+		TitleCaseToken{},
+	} {
+		if _, err := Compile(tok); err == nil {
+			t.Errorf("%v: expected an error", tok)
+		}
+	}
+}
+
+func TestProgram_errors(t *testing.T) {
+	program, err := Compile(SequenceToken{Tokens: []StringConstructionToken{
+		LiteralToken{Literal: "a"},
+		ListSelectionToken{ChoiceListName: "missing"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := program.Generate(context.Background(), fixedRandomSource{}, emptyContext); !errors.Is(err, ErrMissingList) {
+		t.Errorf("Expected ErrMissingList, got %v", err)
+	}
+
+	c, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := program.Generate(c, fixedRandomSource{}, emptyContext); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the generation to be cancelled, got %v", err)
+	}
+
+	program, err = Compile(TitleCaseToken{Base: LiteralToken{Literal: "a long title"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, budget := range []Budget{{MaxSteps: 2}, {MaxDepth: 1}, {MaxOutput: 5}} {
+		ctx := StringConstructionContext{Budget: budget}
+		if _, err := program.Generate(context.Background(), fixedRandomSource{}, ctx); !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("%+v: expected ErrBudgetExceeded, got %v", budget, err)
+		}
+	}
+}
+
+type shoutToken struct{}
+
+func (shoutToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
+	return "HEY", nil
+}
+
+func TestProgram_runsUnknownTokens(t *testing.T) {
+	program, err := Compile(SequenceToken{Tokens: []StringConstructionToken{shoutToken{}, LiteralToken{Literal: "!"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := program.Generate(context.Background(), fixedRandomSource{}, emptyContext); got != "HEY!" {
+		t.Errorf("Expected 'HEY!', got '%s'", got)
+	}
+}
+
+func TestTitleCase_matchesCaser(t *testing.T) {
+	caser := cases.Title(language.AmericanEnglish, cases.NoLower)
+	slow := func(str string) string {
+		words := strings.Split(str, " ")
+		for i, word := range words {
+			if i == 0 || i == len(words)-1 {
+				words[i] = caser.String(word)
+			} else if _, ok := uncapitalizedWords[word]; ok {
+				words[i] = strings.ToLower(word)
+			} else {
+				words[i] = caser.String(word)
+			}
+		}
+		return strings.Join(words, " ")
+	}
+	for _, str := range []string{
+		"the lord of the rings",
+		"aaliyah the ghastly-accuser of anchovies",
+		"o'brien mcTavish",
+		"élan of ünder",
+		"21st of march and a",
+		"  double  spaced ",
+		"of",
+		"",
+	} {
+		if got, expected := titleCase(str), slow(str); got != expected {
+			t.Errorf("%q: expected %q, got %q", str, expected, got)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package token
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultWidth is the line width Format aims for when width is zero.
+const DefaultWidth = 80
+
+const indentUnit = "  "
+
+func (token LiteralToken) String() string          { return compact(token) }
+func (token SubstitutionToken) String() string     { return compact(token) }
+func (token SequenceToken) String() string         { return compact(token) }
+func (token OptionalToken) String() string         { return compact(token) }
+func (token OneofListToken) String() string        { return compact(token) }
+func (token ListSelectionToken) String() string    { return compact(token) }
+func (token OrdinalSelectionToken) String() string { return compact(token) }
+func (token TitleCaseToken) String() string        { return compact(token) }
+
+// formatWeight writes a weight or odds in the plain decimal form the parser
+// reads, such as 0.75 rather than .75 or 7.5e-01.
+func formatWeight(w float64) string {
+	return strconv.FormatFloat(w, 'f', -1, 64)
+}
+
+// writeLiteral writes a double-quoted literal, escaping quotes, backslashes
+// and control characters.
+func writeLiteral(sb *strings.Builder, literal string) {
+	sb.WriteString(`"`)
+	for _, r := range literal {
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(sb, `\u{%x}`, r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteString(`"`)
+}
+
+// compact writes tok in template syntax on one line. It doesn't check that
+// the result parses; Format does.
+func compact(tok StringConstructionToken) string {
+	var sb strings.Builder
+	writeCompact(&sb, tok)
+	return sb.String()
+}
+
+func writeCompact(sb *strings.Builder, tok StringConstructionToken) {
+	switch tok := tok.(type) {
+	case LiteralToken:
+		writeLiteral(sb, tok.Literal)
+	case SubstitutionToken:
+		sb.WriteString("@" + tok.Key)
+	case ListSelectionToken:
+		if tok.Filtered {
+			sb.WriteString("$" + tok.ChoiceListName)
+		} else {
+			sb.WriteString("#" + tok.ChoiceListName)
+		}
+	case OrdinalSelectionToken:
+		sb.WriteString("%" + strconv.Itoa(tok.Max))
+	case SequenceToken:
+		for i, child := range tok.Tokens {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			writeCompact(sb, child)
+		}
+	case OptionalToken:
+		sb.WriteString("{" + formatWeight(tok.Odds) + " ")
+		writeCompact(sb, tok.Token)
+		sb.WriteString("}")
+	case OneofListToken:
+		sb.WriteString("[")
+		for i, entry := range tok.Entries {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(formatWeight(entry.Weight) + " ")
+			writeCompact(sb, entry.Token)
+		}
+		sb.WriteString("]")
+	case TitleCaseToken:
+		sb.WriteString("-")
+		writeCompact(sb, tok.Base)
+		sb.WriteString("+")
+	default:
+		fmt.Fprintf(sb, "<%T>", tok)
+	}
+}
+
+// Format writes tok in canonical template syntax. Anything that fits in width
+// columns is written on one line; longer oneof lists get one entry per line,
+// and longer optionals and sequences are split and indented. Weights are
+// written as plain decimals.
+//
+// The result parses back to an equivalent token, except that sequences nested
+// directly inside sequences are flattened and one-token sequences are
+// unwrapped. Format returns an error if tok can't be written in the syntax,
+// such as a list name containing a digit or a weight that is negative.
+func Format(tok StringConstructionToken, width int) (string, error) {
+	if err := checkFormattable(tok); err != nil {
+		return "", err
+	}
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	p := printer{width: width}
+	p.block(flatten(tok), 0)
+	p.sb.WriteString("\n")
+	return p.sb.String(), nil
+}
+
+// flatten merges sequences nested directly inside sequences, which the syntax
+// can't tell apart, and unwraps one-token sequences.
+func flatten(tok StringConstructionToken) StringConstructionToken {
+	switch tok := tok.(type) {
+	case SequenceToken:
+		tokens := []StringConstructionToken{}
+		for _, child := range tok.Tokens {
+			child = flatten(child)
+			if inner, ok := child.(SequenceToken); ok {
+				tokens = append(tokens, inner.Tokens...)
+			} else {
+				tokens = append(tokens, child)
+			}
+		}
+		if len(tokens) == 1 {
+			return tokens[0]
+		}
+		return SequenceToken{Tokens: tokens}
+	case OptionalToken:
+		return OptionalToken{Token: flatten(tok.Token), Odds: tok.Odds}
+	case OneofListToken:
+		entries := make([]OneofListEntry, len(tok.Entries))
+		for i, entry := range tok.Entries {
+			entries[i] = OneofListEntry{Token: flatten(entry.Token), Weight: entry.Weight}
+		}
+		return OneofListToken{Entries: entries}
+	case TitleCaseToken:
+		return TitleCaseToken{Base: flatten(tok.Base)}
+	default:
+		return tok
+	}
+}
+
+func checkName(kind string, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s has no name", kind)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && r != '_' {
+			return fmt.Errorf("%s %q can only contain letters and underscores", kind, name)
+		}
+	}
+	return nil
+}
+
+// checkFormattable returns an error if some token in tok can't be written in
+// template syntax.
+func checkFormattable(tok StringConstructionToken) error {
+	switch tok := tok.(type) {
+	case LiteralToken:
+	case SubstitutionToken:
+		return checkName("substitution", tok.Key)
+	case ListSelectionToken:
+		return checkName("list", tok.ChoiceListName)
+	case OrdinalSelectionToken:
+		if tok.Max < 2 {
+			return fmt.Errorf("ordinal max %d must be at least 2", tok.Max)
+		}
+	case SequenceToken:
+		if flattened, ok := flatten(tok).(SequenceToken); ok && len(flattened.Tokens) == 0 {
+			return fmt.Errorf("empty sequence")
+		}
+		for _, child := range tok.Tokens {
+			if err := checkFormattable(child); err != nil {
+				return err
+			}
+		}
+	case OptionalToken:
+		if err := ValidateOdds(tok.Odds); err != nil {
+			return err
+		}
+		return checkFormattable(tok.Token)
+	case OneofListToken:
+		if err := ValidateWeights(tok.Entries); err != nil {
+			return err
+		}
+		for _, entry := range tok.Entries {
+			if err := checkFormattable(entry.Token); err != nil {
+				return err
+			}
+		}
+	case TitleCaseToken:
+		return checkFormattable(tok.Base)
+	default:
+		return fmt.Errorf("cannot format token of type %T", tok)
+	}
+	return nil
+}
+
+// printer writes a token tree over several lines.
+type printer struct {
+	sb    strings.Builder
+	width int
+	// column is the length of the current line.
+	column int
+}
+
+func (p *printer) write(s string) {
+	p.sb.WriteString(s)
+	p.column += utf8.RuneCountInString(s)
+}
+
+func (p *printer) newline(indent int) {
+	p.sb.WriteString("\n")
+	p.column = 0
+	p.write(strings.Repeat(indentUnit, indent))
+}
+
+// block writes tok starting at the current column. Any lines after the first
+// start at indent.
+func (p *printer) block(tok StringConstructionToken, indent int) {
+	if c := compact(tok); p.column+utf8.RuneCountInString(c) <= p.width {
+		p.write(c)
+		return
+	}
+
+	switch tok := tok.(type) {
+	case SequenceToken:
+		for i, child := range tok.Tokens {
+			if i > 0 {
+				p.newline(indent)
+			}
+			p.block(child, indent)
+		}
+	case OptionalToken:
+		p.write("{" + formatWeight(tok.Odds))
+		p.newline(indent + 1)
+		p.block(tok.Token, indent+1)
+		p.newline(indent)
+		p.write("}")
+	case OneofListToken:
+		p.write("[")
+		for i, entry := range tok.Entries {
+			p.newline(indent + 1)
+			p.write(formatWeight(entry.Weight) + " ")
+			p.block(entry.Token, indent+2)
+			if i < len(tok.Entries)-1 {
+				p.write(",")
+			}
+		}
+		p.newline(indent)
+		p.write("]")
+	case TitleCaseToken:
+		p.write("-")
+		p.block(tok.Base, indent)
+		p.write("+")
+	default:
+		p.write(compact(tok))
+	}
+}
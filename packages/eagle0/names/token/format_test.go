@@ -0,0 +1,75 @@
+package token
+
+import "testing"
+
+func TestString(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		ListSelectionToken{ChoiceListName: "name", Filtered: true},
+		OptionalToken{Token: LiteralToken{Literal: " the "}, Odds: .5},
+		TitleCaseToken{Base: OneofListToken{Entries: []OneofListEntry{
+			{Token: ListSelectionToken{ChoiceListName: "noun"}, Weight: 0.75},
+			{Token: OrdinalSelectionToken{Max: 12}, Weight: 1},
+			{Token: SubstitutionToken{Key: "title"}, Weight: 0.0001},
+		}}},
+	}}
+	expected := `$name {0.5 " the "} -[0.75 #noun, 1 %12, 0.0001 @title]+`
+	if got := tok.String(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	tok = SequenceToken{Tokens: []StringConstructionToken{
+		LiteralToken{Literal: "say \"hi\"\n"},
+		LiteralToken{Literal: "a\\b\tc"},
+	}}
+	expected = `"say \"hi\"\n" "a\\b\u{9}c"`
+	if got := tok.String(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestFormat_splitsLongLists(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		ListSelectionToken{ChoiceListName: "name", Filtered: true},
+		OneofListToken{Entries: []OneofListEntry{
+			{Token: LiteralToken{Literal: " of the mountain"}, Weight: 0.5},
+			{Token: SequenceToken{Tokens: []StringConstructionToken{
+				LiteralToken{Literal: " "},
+				SequenceToken{Tokens: []StringConstructionToken{ListSelectionToken{ChoiceListName: "noun", Filtered: true}}},
+			}}, Weight: 0.5},
+		}},
+	}}
+
+	formatted, err := Format(tok, 30)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "$name\n[\n  0.5 \" of the mountain\",\n  0.5 \" \" $noun\n]\n"
+	if formatted != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+
+	formatted, err = Format(tok, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected = "$name [0.5 \" of the mountain\", 0.5 \" \" $noun]\n"
+	if formatted != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, formatted)
+	}
+}
+
+func TestFormat_rejectsUnwritableTokens(t *testing.T) {
+	tests := map[string]StringConstructionToken{
+		"list with digits": ListSelectionToken{ChoiceListName: "list2"},
+		"empty key":        SubstitutionToken{},
+		"negative weight":  OneofListToken{Entries: []OneofListEntry{{Token: LiteralToken{Literal: "a"}, Weight: -1}}},
+		"ordinal max of 1": OrdinalSelectionToken{Max: 1},
+		"empty sequence":   SequenceToken{},
+		"missing child":    TitleCaseToken{},
+	}
+	for name, tok := range tests {
+		if _, err := Format(tok, 0); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The values of the "type" field that tags each node of a JSON token tree.
+const (
+	literalType      = "literal"
+	substitutionType = "substitution"
+	sequenceType     = "sequence"
+	optionalType     = "optional"
+	oneofType        = "oneof"
+	listType         = "list"
+	ordinalType      = "ordinal"
+	titleCaseType    = "titlecase"
+)
+
+// node is the JSON form of a token. Type says which token it is, and only the
+// fields that token uses are set.
+type node struct {
+	Type     string            `json:"type"`
+	Literal  *string           `json:"literal,omitempty"`
+	Key      string            `json:"key,omitempty"`
+	List     string            `json:"list,omitempty"`
+	Filtered bool              `json:"filtered,omitempty"`
+	Max      int               `json:"max,omitempty"`
+	Odds     *float64          `json:"odds,omitempty"`
+	Token    json.RawMessage   `json:"token,omitempty"`
+	Tokens   []json.RawMessage `json:"tokens,omitempty"`
+	Entries  []entryNode       `json:"entries,omitempty"`
+}
+
+type entryNode struct {
+	Weight float64         `json:"weight"`
+	Token  json.RawMessage `json:"token"`
+}
+
+// Marshal returns the JSON form of a token tree. Every node is an object whose
+// "type" field names the token, for example
+//
+//	{"type": "sequence", "tokens": [
+//	  {"type": "list", "list": "name", "filtered": true},
+//	  {"type": "literal", "literal": " the "},
+//	  {"type": "titlecase", "token": {"type": "list", "list": "adjective"}}
+//	]}
+func Marshal(tok StringConstructionToken) ([]byte, error) {
+	n, err := toNode(tok)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(n)
+}
+
+// Unmarshal builds a token tree from its JSON form, as written by Marshal.
+func Unmarshal(data []byte) (StringConstructionToken, error) {
+	var n node
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	return n.token()
+}
+
+func marshalChild(tok StringConstructionToken) (json.RawMessage, error) {
+	n, err := toNode(tok)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(n)
+}
+
+func toNode(tok StringConstructionToken) (node, error) {
+	switch tok := tok.(type) {
+	case LiteralToken:
+		return node{Type: literalType, Literal: &tok.Literal}, nil
+	case SubstitutionToken:
+		return node{Type: substitutionType, Key: tok.Key}, nil
+	case ListSelectionToken:
+		return node{Type: listType, List: tok.ChoiceListName, Filtered: tok.Filtered}, nil
+	case OrdinalSelectionToken:
+		return node{Type: ordinalType, Max: tok.Max}, nil
+	case SequenceToken:
+		n := node{Type: sequenceType, Tokens: []json.RawMessage{}}
+		for _, child := range tok.Tokens {
+			data, err := marshalChild(child)
+			if err != nil {
+				return node{}, err
+			}
+			n.Tokens = append(n.Tokens, data)
+		}
+		return n, nil
+	case OptionalToken:
+		data, err := marshalChild(tok.Token)
+		if err != nil {
+			return node{}, err
+		}
+		return node{Type: optionalType, Odds: &tok.Odds, Token: data}, nil
+	case OneofListToken:
+		n := node{Type: oneofType, Entries: []entryNode{}}
+		for _, entry := range tok.Entries {
+			data, err := marshalChild(entry.Token)
+			if err != nil {
+				return node{}, err
+			}
+			n.Entries = append(n.Entries, entryNode{Weight: entry.Weight, Token: data})
+		}
+		return n, nil
+	case TitleCaseToken:
+		data, err := marshalChild(tok.Base)
+		if err != nil {
+			return node{}, err
+		}
+		return node{Type: titleCaseType, Token: data}, nil
+	default:
+		return node{}, fmt.Errorf("cannot marshal token of type %T", tok)
+	}
+}
+
+func unmarshalChild(data json.RawMessage, parent string) (StringConstructionToken, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s token has no child token", parent)
+	}
+	return Unmarshal(data)
+}
+
+func (n node) token() (StringConstructionToken, error) {
+	switch n.Type {
+	case literalType:
+		if n.Literal == nil {
+			return nil, fmt.Errorf("literal token has no literal")
+		}
+		return LiteralToken{Literal: *n.Literal}, nil
+	case substitutionType:
+		if n.Key == "" {
+			return nil, fmt.Errorf("substitution token has no key")
+		}
+		return SubstitutionToken{Key: n.Key}, nil
+	case listType:
+		if n.List == "" {
+			return nil, fmt.Errorf("list token has no list")
+		}
+		return ListSelectionToken{ChoiceListName: n.List, Filtered: n.Filtered}, nil
+	case ordinalType:
+		if n.Max < 2 {
+			return nil, fmt.Errorf("ordinal token must have a max of at least 2, got %d", n.Max)
+		}
+		return OrdinalSelectionToken{Max: n.Max}, nil
+	case sequenceType:
+		tokens := []StringConstructionToken{}
+		for _, data := range n.Tokens {
+			child, err := Unmarshal(data)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, child)
+		}
+		return SequenceToken{Tokens: tokens}, nil
+	case optionalType:
+		if n.Odds == nil {
+			return nil, fmt.Errorf("optional token has no odds")
+		}
+		child, err := unmarshalChild(n.Token, optionalType)
+		if err != nil {
+			return nil, err
+		}
+		return NewOptionalToken(child, *n.Odds)
+	case oneofType:
+		entries := []OneofListEntry{}
+		for _, entry := range n.Entries {
+			child, err := unmarshalChild(entry.Token, oneofType)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, OneofListEntry{Token: child, Weight: entry.Weight})
+		}
+		return NewOneofListToken(entries)
+	case titleCaseType:
+		child, err := unmarshalChild(n.Token, titleCaseType)
+		if err != nil {
+			return nil, err
+		}
+		return TitleCaseToken{Base: child}, nil
+	case "":
+		return nil, fmt.Errorf("token has no type")
+	default:
+		return nil, fmt.Errorf("unknown token type %q", n.Type)
+	}
+}
@@ -0,0 +1,66 @@
+package token
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+)
+
+func TestMarshal_roundTrips(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		ListSelectionToken{ChoiceListName: "name", Filtered: true},
+		LiteralToken{Literal: ""},
+		OptionalToken{Token: LiteralToken{Literal: " the "}, Odds: 0},
+		TitleCaseToken{Base: OneofListToken{Entries: []OneofListEntry{
+			{Token: ListSelectionToken{ChoiceListName: "adjective"}, Weight: 2},
+			{Token: OrdinalSelectionToken{Max: 100}, Weight: 0.5},
+			{Token: SubstitutionToken{Key: "title"}, Weight: 1},
+		}}},
+		SequenceToken{Tokens: []StringConstructionToken{}},
+	}}
+
+	data, err := Marshal(tok)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if diff := cmp.Diff(tok, got); diff != "" {
+		t.Errorf("Expected the token to round-trip (-want +got):\n%s", diff)
+	}
+}
+
+func TestMarshal_tagsNodes(t *testing.T) {
+	data, err := Marshal(TitleCaseToken{Base: ListSelectionToken{ChoiceListName: "noun"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"type":"titlecase","token":{"type":"list","list":"noun"}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestUnmarshal_rejectsInvalidTrees(t *testing.T) {
+	tests := map[string]string{
+		"not json":         `{"type":`,
+		"no type":          `{"literal": "a"}`,
+		"unknown type":     `{"type": "shout"}`,
+		"no literal":       `{"type": "literal"}`,
+		"no list":          `{"type": "list"}`,
+		"small ordinal":    `{"type": "ordinal", "max": 1}`,
+		"no odds":          `{"type": "optional", "token": {"type": "literal", "literal": "a"}}`,
+		"no child":         `{"type": "titlecase"}`,
+		"no entries":       `{"type": "oneof", "entries": []}`,
+		"bad nested child": `{"type": "sequence", "tokens": [{"type": "list"}]}`,
+	}
+	for name, data := range tests {
+		if _, err := Unmarshal([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if name == "unknown type" && !strings.Contains(err.Error(), "shout") {
+			t.Errorf("%s: expected the error to name the type, got %v", name, err)
+		}
+	}
+}
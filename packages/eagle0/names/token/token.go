@@ -1,17 +1,42 @@
 package token
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
-	"strconv"
 	"strings"
+	"sync"
+)
+
+var (
+	// ErrMissingList is returned when a token refers to a list that does not exist.
+	ErrMissingList = errors.New("missing list")
+	// ErrEmptyList is returned when a list exists but has no entries to choose from.
+	ErrEmptyList = errors.New("empty list")
+	// ErrMissingKey is returned when a substitution key has no value.
+	ErrMissingKey = errors.New("missing key")
 )
 
 type StringConstructionContext struct {
-	ChoiceListMap           map[string][]string
-	UnfilteredChoiceListMap map[string][]string
-	LiteralSubstitutions    map[string]string
+	Lexicon *lexicon.Lexicon
+	// Filter restricts the entries filtered list selections choose from.
+	// Unfiltered selections choose from every entry.
+	Filter               lexicon.Filter
+	LiteralSubstitutions map[string]string
+
+	// Context, if set, stops generation once it is done.
+	Context context.Context
+	// Budget limits the work done generating one string.
+	Budget Budget
+
+	usage *usage
+	depth int
+	// trace is the Derivation of the token being evaluated, when tracing.
+	trace *Derivation
 }
 
 type TokenRandomSource interface {
@@ -28,6 +53,10 @@ type LiteralToken struct {
 }
 
 func (token LiteralToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
+	}
 	return token.Literal, nil
 }
 
@@ -36,10 +65,15 @@ type SubstitutionToken struct {
 }
 
 func (token SubstitutionToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
+	}
+	ctx.record(func(d *Derivation) { d.Key = token.Key })
 	value, ok := ctx.LiteralSubstitutions[token.Key]
 
 	if !ok {
-		return "", fmt.Errorf("missing key: %s", token.Key)
+		return "", fmt.Errorf("%w: %s", ErrMissingKey, token.Key)
 	}
 	return value, nil
 }
@@ -49,15 +83,22 @@ type SequenceToken struct {
 }
 
 func (token SequenceToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
-	result := ""
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
+	}
+	var result strings.Builder
 	for _, token := range token.Tokens {
-		next, err := token.Next(rand, ctx)
+		next, err := ctx.next(token, rand)
 		if err != nil {
 			return "", err
 		}
-		result += next
+		result.WriteString(next)
+		if err := ctx.checkOutput(result.Len()); err != nil {
+			return "", err
+		}
 	}
-	return result, nil
+	return result.String(), nil
 }
 
 type OptionalToken struct {
@@ -66,9 +107,21 @@ type OptionalToken struct {
 }
 
 func (token OptionalToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateOdds(token.Odds); err != nil {
+		return "", err
+	}
 	r := rand.Float64()
-	if r < token.Odds {
-		return token.Token.Next(rand, ctx)
+	fired := r < token.Odds
+	ctx.record(func(d *Derivation) {
+		d.Odds = &token.Odds
+		d.Fired = &fired
+	})
+	if fired {
+		return ctx.next(token.Token, rand)
 	}
 	return "", nil
 }
@@ -79,27 +132,33 @@ type OneofListEntry struct {
 }
 
 func (entry OneofListEntry) ToString(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
-	return entry.Token.Next(rand, ctx)
+	return ctx.next(entry.Token, rand)
 }
 
 type OneofListToken struct {
 	Entries []OneofListEntry
+
+	// cumulative holds the running total of the entries' weights when the
+	// token was made by NewOneofListToken.
+	cumulative []float64
 }
 
 func (token OneofListToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
-	totalWeight := 0.0
-	for _, entry := range token.Entries {
-		totalWeight += entry.Weight
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
 	}
-
-	randomValue := rand.Float64() * totalWeight
-	for _, entry := range token.Entries {
-		randomValue -= entry.Weight
-		if randomValue <= 0 {
-			return entry.ToString(rand, ctx)
+	cumulative := token.cumulative
+	if cumulative == nil {
+		if err := ValidateWeights(token.Entries); err != nil {
+			return "", err
 		}
+		cumulative = cumulativeWeights(token.Entries)
 	}
-	panic("Should not reach here")
+
+	i := pick(cumulative, rand.Float64())
+	ctx.record(func(d *Derivation) { d.Chosen = &i })
+	return token.Entries[i].ToString(rand, ctx)
 }
 
 type ListSelectionToken struct {
@@ -108,17 +167,29 @@ type ListSelectionToken struct {
 }
 
 func (token ListSelectionToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
-	var list []string
-	var ok bool
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
+	}
+	filter := lexicon.Filter{}
 	if token.Filtered {
-		list, ok = ctx.ChoiceListMap[token.ChoiceListName]
-	} else {
-		list, ok = ctx.UnfilteredChoiceListMap[token.ChoiceListName]
+		filter = ctx.Filter
 	}
+	view, ok := ctx.Lexicon.Select(token.ChoiceListName, filter)
 	if !ok {
-		return "", fmt.Errorf("missing list: %s", token.ChoiceListName)
+		return "", fmt.Errorf("%w: %s", ErrMissingList, token.ChoiceListName)
+	}
+	if view.Len() == 0 {
+		return "", fmt.Errorf("%w: %s", ErrEmptyList, token.ChoiceListName)
 	}
-	return list[rand.Intn(len(list))], nil
+	i := view.PickIndex(rand)
+	ctx.record(func(d *Derivation) {
+		d.List = token.ChoiceListName
+		index := view.ListIndex(i)
+		d.Index = &index
+		d.Choices = view.Len()
+	})
+	return view.At(i).Text, nil
 }
 
 type OrdinalSelectionToken struct {
@@ -126,21 +197,12 @@ type OrdinalSelectionToken struct {
 }
 
 func (token OrdinalSelectionToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
-	value := rand.Intn(token.Max-1) + 1
-	aval := strconv.Itoa(value)
-	if value%100 == 11 || value%100 == 12 || value%100 == 13 {
-		return aval + "th", nil
-	}
-	switch value % 10 {
-	case 1:
-		return aval + "st", nil
-	case 2:
-		return aval + "nd", nil
-	case 3:
-		return aval + "rd", nil
-	default:
-		return aval + "th", nil
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
 	}
+	value := rand.Intn(token.Max-1) + 1
+	return string(appendOrdinal(nil, value)), nil
 }
 
 var uncapitalizedWords = map[string]bool{
@@ -152,23 +214,69 @@ type TitleCaseToken struct {
 }
 
 func (token TitleCaseToken) Next(rand TokenRandomSource, ctx StringConstructionContext) (string, error) {
-	caser := cases.Title(language.AmericanEnglish, cases.NoLower)
-	str, err := token.Base.Next(rand, ctx)
+	ctx, err := ctx.enter()
+	if err != nil {
+		return "", err
+	}
+	str, err := ctx.next(token.Base, rand)
 	if err != nil {
 		return "", err
 	}
+	return titleCase(str), nil
+}
+
+// casers holds title casers for reuse, since making one is slow and a caser
+// can't be shared between goroutines.
+var casers = sync.Pool{New: func() any {
+	caser := cases.Title(language.AmericanEnglish, cases.NoLower)
+	return &caser
+}}
 
-	words := strings.Split(str, " ")
-	newWords := make([]string, len(words))
+// titleCase capitalizes each word of str except short joining words, which
+// are left in lower case unless they come first or last.
+func titleCase(str string) string {
+	return string(appendTitleCase(nil, []byte(str)))
+}
 
-	for i, word := range words {
-		if i == 0 || i == len(words)-1 {
-			newWords[i] = caser.String(word)
-		} else if _, ok := uncapitalizedWords[word]; ok {
-			newWords[i] = strings.ToLower(word)
+// appendTitleCase appends the title case of src to dst. src must not overlap
+// dst's spare capacity.
+func appendTitleCase(dst []byte, src []byte) []byte {
+	words := bytes.Count(src, []byte(" ")) + 1
+	for i := 0; i < words; i++ {
+		word := src
+		if end := bytes.IndexByte(src, ' '); end >= 0 {
+			word, src = src[:end], src[end+1:]
+		}
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		if i > 0 && i < words-1 && uncapitalizedWords[string(word)] {
+			dst = append(dst, word...)
 		} else {
-			newWords[i] = caser.String(word)
+			dst = appendTitleWord(dst, word)
 		}
 	}
-	return strings.Join(newWords, " "), nil
+	return dst
+}
+
+// appendTitleWord appends word with its first letter capitalized. Words of
+// ASCII letters are done directly; anything else, such as a hyphenated word
+// or one with accents, is left to a caser.
+func appendTitleWord(dst []byte, word []byte) []byte {
+	for _, c := range word {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			caser := casers.Get().(*cases.Caser)
+			defer casers.Put(caser)
+			return append(dst, caser.Bytes(word)...)
+		}
+	}
+	if len(word) == 0 {
+		return dst
+	}
+	first := word[0]
+	if 'a' <= first && first <= 'z' {
+		first -= 'a' - 'A'
+	}
+	dst = append(dst, first)
+	return append(dst, word[1:]...)
 }
@@ -1,6 +1,8 @@
 package token
 
 import (
+	"errors"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
 	"testing"
 )
 
@@ -184,9 +186,9 @@ func TestListSelectionToken(t *testing.T) {
 	}
 
 	contextWithChoices := StringConstructionContext{
-		ChoiceListMap: map[string][]string{
+		Lexicon: lexicon.FromStrings(map[string][]string{
 			"names": {"Option1", "Option2", "Option3"},
-		},
+		}),
 	}
 
 	r := fixedRandomSource{IntNValue: 0}
@@ -244,3 +246,42 @@ func TestTitleCaseToken_preserveExistingCapitals(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expected, result)
 	}
 }
+
+func TestListSelectionToken_missingList(t *testing.T) {
+	token := ListSelectionToken{ChoiceListName: "names", Filtered: true}
+
+	_, err := token.Next(fixedRandomSource{}, emptyContext)
+	if !errors.Is(err, ErrMissingList) {
+		t.Errorf("Expected ErrMissingList, got %v", err)
+	}
+}
+
+func TestListSelectionToken_emptyList(t *testing.T) {
+	token := ListSelectionToken{ChoiceListName: "names", Filtered: true}
+	contextWithEmptyList := StringConstructionContext{
+		Lexicon: lexicon.FromStrings(map[string][]string{
+			"names": {},
+		}),
+	}
+
+	_, err := token.Next(fixedRandomSource{}, contextWithEmptyList)
+	if !errors.Is(err, ErrEmptyList) {
+		t.Errorf("Expected ErrEmptyList, got %v", err)
+	}
+}
+
+func TestListSelectionToken_filtered(t *testing.T) {
+	lex := lexicon.New()
+	lex.Add("names", lexicon.Entry{Text: "Bob", Tags: []string{lexicon.Male}})
+	lex.Add("names", lexicon.Entry{Text: "Ada", Tags: []string{lexicon.Female}})
+	ctx := StringConstructionContext{Lexicon: lex, Filter: lexicon.Filter{Gender: lexicon.Female}}
+
+	filtered, err := ListSelectionToken{ChoiceListName: "names", Filtered: true}.Next(fixedRandomSource{}, ctx)
+	if err != nil || filtered != "Ada" {
+		t.Errorf("Expected 'Ada', got '%s' (%v)", filtered, err)
+	}
+	unfiltered, err := ListSelectionToken{ChoiceListName: "names"}.Next(fixedRandomSource{}, ctx)
+	if err != nil || unfiltered != "Bob" {
+		t.Errorf("Expected 'Bob', got '%s' (%v)", unfiltered, err)
+	}
+}
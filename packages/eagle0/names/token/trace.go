@@ -0,0 +1,84 @@
+package token
+
+import "context"
+
+// Derivation records how one token produced its part of a generated string.
+// Its children are the nested tokens that were evaluated, in order.
+type Derivation struct {
+	// Type is the token's type, as in the JSON form written by Marshal.
+	Type   string `json:"type"`
+	Output string `json:"output"`
+
+	// Chosen is the position of the oneof entry that was picked.
+	Chosen *int `json:"chosen,omitempty"`
+	// Odds and Fired are an optional's odds and whether it produced anything.
+	Odds  *float64 `json:"odds,omitempty"`
+	Fired *bool    `json:"fired,omitempty"`
+	// List, Index and Choices are the list selected from, the position in
+	// the list of the entry picked, and how many entries were eligible.
+	List    string `json:"list,omitempty"`
+	Index   *int   `json:"index,omitempty"`
+	Choices int    `json:"choices,omitempty"`
+	// Key is the substitution key looked up.
+	Key string `json:"key,omitempty"`
+
+	Children []*Derivation `json:"children,omitempty"`
+}
+
+func typeName(tok StringConstructionToken) string {
+	switch tok.(type) {
+	case LiteralToken:
+		return literalType
+	case SubstitutionToken:
+		return substitutionType
+	case SequenceToken:
+		return sequenceType
+	case OptionalToken:
+		return optionalType
+	case OneofListToken:
+		return oneofType
+	case ListSelectionToken:
+		return listType
+	case OrdinalSelectionToken:
+		return ordinalType
+	case TitleCaseToken:
+		return titleCaseType
+	default:
+		return ""
+	}
+}
+
+// next evaluates a nested token. When tracing, it adds a Derivation for tok
+// to the current one and makes it current while tok is evaluated.
+func (ctx StringConstructionContext) next(tok StringConstructionToken, rand TokenRandomSource) (string, error) {
+	if ctx.trace == nil {
+		return tok.Next(rand, ctx)
+	}
+	d := &Derivation{Type: typeName(tok)}
+	ctx.trace.Children = append(ctx.trace.Children, d)
+	ctx.trace = d
+	result, err := tok.Next(rand, ctx)
+	d.Output = result
+	return result, err
+}
+
+// record lets a token add what it chose to its Derivation, if tracing.
+func (ctx StringConstructionContext) record(note func(d *Derivation)) {
+	if ctx.trace != nil {
+		note(ctx.trace)
+	}
+}
+
+// Trace is like Generate, but also returns the Derivation of the string: the
+// path taken through tok, with which oneof entries won, which optionals
+// fired and which list entries were picked. The Derivation is returned even
+// on error, showing how far generation got.
+func Trace(c context.Context, tok StringConstructionToken, rand TokenRandomSource, ctx StringConstructionContext) (string, *Derivation, error) {
+	root := &Derivation{}
+	ctx.trace = root
+	result, err := Generate(c, tok, rand, ctx)
+	if len(root.Children) == 0 {
+		return result, nil, err
+	}
+	return result, root.Children[0], err
+}
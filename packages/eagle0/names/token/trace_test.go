@@ -0,0 +1,65 @@
+package token
+
+import (
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nolen777/name-generator/packages/eagle0/names/lexicon"
+	"testing"
+)
+
+func intPtr(i int) *int           { return &i }
+func floatPtr(f float64) *float64 { return &f }
+func boolPtr(b bool) *bool        { return &b }
+
+func TestTrace_recordsChoices(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		ListSelectionToken{ChoiceListName: "name", Filtered: true},
+		OptionalToken{Token: LiteralToken{Literal: " the "}, Odds: 0.5},
+		OptionalToken{Token: LiteralToken{Literal: "never"}, Odds: 0.1},
+		OneofListToken{Entries: []OneofListEntry{
+			{Token: LiteralToken{Literal: "Bold"}, Weight: 0.2},
+			{Token: TitleCaseToken{Base: SubstitutionToken{Key: "title"}}, Weight: 0.8},
+		}},
+	}}
+	ctx := StringConstructionContext{
+		Lexicon:              lexicon.FromStrings(map[string][]string{"name": {"Ada", "Bob"}}),
+		LiteralSubstitutions: map[string]string{"title": "wise"},
+	}
+
+	result, derivation, err := Trace(context.Background(), tok, fixedRandomSource{IntNValue: 1, Float64Value: 0.3}, ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result != "Bob the Wise" {
+		t.Errorf("Expected 'Bob the Wise', got '%s'", result)
+	}
+	expected := &Derivation{Type: "sequence", Output: "Bob the Wise", Children: []*Derivation{
+		{Type: "list", Output: "Bob", List: "name", Index: intPtr(1), Choices: 2},
+		{Type: "optional", Output: " the ", Odds: floatPtr(0.5), Fired: boolPtr(true), Children: []*Derivation{
+			{Type: "literal", Output: " the "},
+		}},
+		{Type: "optional", Output: "", Odds: floatPtr(0.1), Fired: boolPtr(false)},
+		{Type: "oneof", Output: "Wise", Chosen: intPtr(1), Children: []*Derivation{
+			{Type: "titlecase", Output: "Wise", Children: []*Derivation{
+				{Type: "substitution", Output: "wise", Key: "title"},
+			}},
+		}},
+	}}
+	if diff := cmp.Diff(expected, derivation); diff != "" {
+		t.Errorf("Unexpected derivation (-want +got):\n%s", diff)
+	}
+}
+
+func TestTrace_returnsPartialDerivationOnError(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		LiteralToken{Literal: "a"},
+		ListSelectionToken{ChoiceListName: "missing"},
+	}}
+	_, derivation, err := Trace(context.Background(), tok, fixedRandomSource{}, emptyContext)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if derivation == nil || len(derivation.Children) != 2 || derivation.Children[1].List != "" {
+		t.Errorf("Expected the derivation to stop at the missing list, got %+v", derivation)
+	}
+}
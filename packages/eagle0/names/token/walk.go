@@ -0,0 +1,43 @@
+package token
+
+import "sort"
+
+// Walk calls visit for tok and then for each token nested inside it, depth
+// first in the order they appear.
+func Walk(tok StringConstructionToken, visit func(StringConstructionToken)) {
+	if tok == nil {
+		return
+	}
+	visit(tok)
+	switch tok := tok.(type) {
+	case SequenceToken:
+		for _, child := range tok.Tokens {
+			Walk(child, visit)
+		}
+	case OptionalToken:
+		Walk(tok.Token, visit)
+	case OneofListToken:
+		for _, entry := range tok.Entries {
+			Walk(entry.Token, visit)
+		}
+	case TitleCaseToken:
+		Walk(tok.Base, visit)
+	}
+}
+
+// ListNames returns the sorted, distinct names of the lists tok selects from.
+func ListNames(tok StringConstructionToken) []string {
+	seen := map[string]bool{}
+	Walk(tok, func(t StringConstructionToken) {
+		if selection, ok := t.(ListSelectionToken); ok {
+			seen[selection.ChoiceListName] = true
+		}
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
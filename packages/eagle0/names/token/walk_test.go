@@ -0,0 +1,46 @@
+package token
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestWalk_visitsInOrder(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		LiteralToken{Literal: "a"},
+		OptionalToken{Token: LiteralToken{Literal: "b"}, Odds: 0.5},
+		TitleCaseToken{Base: OneofListToken{Entries: []OneofListEntry{
+			{Token: LiteralToken{Literal: "c"}, Weight: 1},
+			{Token: LiteralToken{Literal: "d"}, Weight: 1},
+		}}},
+	}}
+
+	literals := []string{}
+	count := 0
+	Walk(tok, func(t StringConstructionToken) {
+		count++
+		if literal, ok := t.(LiteralToken); ok {
+			literals = append(literals, literal.Literal)
+		}
+	})
+
+	if !cmp.Equal(literals, []string{"a", "b", "c", "d"}) {
+		t.Errorf("Expected literals in order, got %v", literals)
+	}
+	if count != 8 {
+		t.Errorf("Expected 8 tokens to be visited, got %d", count)
+	}
+}
+
+func TestListNames(t *testing.T) {
+	tok := SequenceToken{Tokens: []StringConstructionToken{
+		ListSelectionToken{ChoiceListName: "surname", Filtered: true},
+		OptionalToken{Token: ListSelectionToken{ChoiceListName: "name"}, Odds: 0.5},
+		ListSelectionToken{ChoiceListName: "name", Filtered: true},
+	}}
+
+	names := ListNames(tok)
+	if !cmp.Equal(names, []string{"name", "surname"}) {
+		t.Errorf("Expected [name surname], got %v", names)
+	}
+}
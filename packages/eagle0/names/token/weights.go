@@ -0,0 +1,123 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// ErrInvalidWeight is returned for an optional whose odds are outside [0, 1],
+// or a oneof list whose weights are negative or all zero.
+var ErrInvalidWeight = errors.New("invalid weight")
+
+// ValidateOdds checks that odds are a probability.
+func ValidateOdds(odds float64) error {
+	if !(odds >= 0 && odds <= 1) {
+		return fmt.Errorf("%w: odds %v must be between 0 and 1", ErrInvalidWeight, odds)
+	}
+	return nil
+}
+
+// ValidateWeights checks that every weight is a non-negative number and that
+// at least one is positive.
+func ValidateWeights(entries []OneofListEntry) error {
+	positive := false
+	for _, entry := range entries {
+		if !(entry.Weight >= 0) || math.IsInf(entry.Weight, 0) {
+			return fmt.Errorf("%w: weight %v must be a non-negative number", ErrInvalidWeight, entry.Weight)
+		}
+		if entry.Weight > 0 {
+			positive = true
+		}
+	}
+	if !positive {
+		return fmt.Errorf("%w: a oneof list needs an entry with a positive weight", ErrInvalidWeight)
+	}
+	return nil
+}
+
+// NewOptionalToken returns an optional token, checking its odds.
+func NewOptionalToken(tok StringConstructionToken, odds float64) (OptionalToken, error) {
+	if err := ValidateOdds(odds); err != nil {
+		return OptionalToken{}, err
+	}
+	return OptionalToken{Token: tok, Odds: odds}, nil
+}
+
+// NewOneofListToken returns a oneof token, checking its weights and
+// computing their running totals once rather than on every Next.
+func NewOneofListToken(entries []OneofListEntry) (OneofListToken, error) {
+	if err := ValidateWeights(entries); err != nil {
+		return OneofListToken{}, err
+	}
+	return OneofListToken{Entries: entries, cumulative: cumulativeWeights(entries)}, nil
+}
+
+func cumulativeWeights(entries []OneofListEntry) []float64 {
+	cumulative := make([]float64, len(entries))
+	total := 0.0
+	for i, entry := range entries {
+		total += entry.Weight
+		cumulative[i] = total
+	}
+	return cumulative
+}
+
+// pick returns the position of the entry that r, in [0, 1), lands on. Entries
+// with no weight are never picked.
+func pick(cumulative []float64, r float64) int {
+	total := cumulative[len(cumulative)-1]
+	target := r * total
+	i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > target })
+	if i == len(cumulative) {
+		// Rounding put the target at the total; take the last entry with
+		// any weight.
+		i = sort.SearchFloat64s(cumulative, total)
+	}
+	return i
+}
+
+// Equal reports whether two oneof lists have the same entries. The running
+// totals computed by NewOneofListToken are ignored.
+func (token OneofListToken) Equal(other OneofListToken) bool {
+	if len(token.Entries) != len(other.Entries) {
+		return false
+	}
+	for i, entry := range token.Entries {
+		if entry.Weight != other.Entries[i].Weight || !equalTokens(entry.Token, other.Entries[i].Token) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalTokens reports whether two token trees are the same, comparing oneof
+// lists with Equal.
+func equalTokens(a StringConstructionToken, b StringConstructionToken) bool {
+	switch a := a.(type) {
+	case SequenceToken:
+		b, ok := b.(SequenceToken)
+		if !ok || len(a.Tokens) != len(b.Tokens) {
+			return false
+		}
+		for i := range a.Tokens {
+			if !equalTokens(a.Tokens[i], b.Tokens[i]) {
+				return false
+			}
+		}
+		return true
+	case OptionalToken:
+		b, ok := b.(OptionalToken)
+		return ok && a.Odds == b.Odds && equalTokens(a.Token, b.Token)
+	case OneofListToken:
+		b, ok := b.(OneofListToken)
+		return ok && a.Equal(b)
+	case TitleCaseToken:
+		b, ok := b.(TitleCaseToken)
+		return ok && equalTokens(a.Base, b.Base)
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
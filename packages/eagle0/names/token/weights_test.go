@@ -0,0 +1,85 @@
+package token
+
+import (
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"math"
+	"testing"
+)
+
+func literalEntries(weights ...float64) []OneofListEntry {
+	entries := []OneofListEntry{}
+	for i, weight := range weights {
+		entries = append(entries, OneofListEntry{Token: LiteralToken{Literal: string(rune('a' + i))}, Weight: weight})
+	}
+	return entries
+}
+
+func TestNewOneofListToken_validatesWeights(t *testing.T) {
+	for name, weights := range map[string][]float64{
+		"none":     {},
+		"all zero": {0, 0},
+		"negative": {1, -0.5},
+		"NaN":      {math.NaN()},
+		"infinite": {math.Inf(1)},
+	} {
+		if _, err := NewOneofListToken(literalEntries(weights...)); !errors.Is(err, ErrInvalidWeight) {
+			t.Errorf("%s: expected ErrInvalidWeight, got %v", name, err)
+		}
+	}
+}
+
+func TestNewOptionalToken_validatesOdds(t *testing.T) {
+	for _, odds := range []float64{-0.1, 1.5, math.NaN()} {
+		if _, err := NewOptionalToken(LiteralToken{Literal: "a"}, odds); !errors.Is(err, ErrInvalidWeight) {
+			t.Errorf("%v: expected ErrInvalidWeight, got %v", odds, err)
+		}
+	}
+	for _, odds := range []float64{0, 1} {
+		if _, err := NewOptionalToken(LiteralToken{Literal: "a"}, odds); err != nil {
+			t.Errorf("%v: expected no error, got %v", odds, err)
+		}
+	}
+}
+
+func TestOneofListToken_neverPicksZeroWeights(t *testing.T) {
+	tok, err := NewOneofListToken(literalEntries(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []float64{0, 0.5, 0.9999999999999999} {
+		result, err := tok.Next(fixedRandomSource{Float64Value: r}, emptyContext)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result != "b" {
+			t.Errorf("Expected 'b' for %v, got '%s'", r, result)
+		}
+	}
+}
+
+func TestOneofListToken_errorsInsteadOfPanicking(t *testing.T) {
+	for _, tok := range []StringConstructionToken{
+		OneofListToken{Entries: literalEntries(0, 0)},
+		OneofListToken{},
+		OptionalToken{Token: LiteralToken{Literal: "a"}, Odds: 2},
+	} {
+		if _, err := tok.Next(fixedRandomSource{Float64Value: 0.5}, emptyContext); !errors.Is(err, ErrInvalidWeight) {
+			t.Errorf("%v: expected ErrInvalidWeight, got %v", tok, err)
+		}
+	}
+}
+
+func TestOneofListToken_equalIgnoresPrecomputedWeights(t *testing.T) {
+	built, err := NewOneofListToken(literalEntries(0.25, 0.75))
+	if err != nil {
+		t.Fatal(err)
+	}
+	literal := OneofListToken{Entries: literalEntries(0.25, 0.75)}
+	if !cmp.Equal(SequenceToken{Tokens: []StringConstructionToken{built}}, SequenceToken{Tokens: []StringConstructionToken{literal}}) {
+		t.Errorf("Expected the tokens to be equal")
+	}
+	if cmp.Equal(built, OneofListToken{Entries: literalEntries(0.5, 0.5)}) {
+		t.Errorf("Expected different weights to differ")
+	}
+}
@@ -0,0 +1,123 @@
+// Package tsv reads and writes the tab-separated word lists.
+//
+// Word lists are exported from spreadsheets and edited by hand, so the reader
+// is lenient: it accepts LF, CRLF and CR line endings, skips a leading byte
+// order mark, and ignores trailing empty fields. A field that
+// starts with a double quote may be quoted, in which case it can contain tabs
+// and line breaks and a doubled quote stands for one quote. A field whose
+// quotes don't close cleanly is read literally, so entries such as
+// `"Big" Sam` survive.
+package tsv
+
+import (
+	"strings"
+)
+
+const bom = "\ufeff"
+
+// Read splits data into records of fields. Trailing empty fields are removed
+// from each record, so a blank line is an empty record. Blank lines at the end
+// are dropped.
+func Read(data []byte) [][]string {
+	s := strings.TrimPrefix(string(data), bom)
+	records := [][]string{}
+	record := []string{}
+	for len(s) > 0 {
+		var field string
+		field, s = readField(s)
+		record = append(record, field)
+
+		switch {
+		case strings.HasPrefix(s, "\t"):
+			s = s[1:]
+			continue
+		case strings.HasPrefix(s, "\r\n"):
+			s = s[2:]
+		case len(s) > 0:
+			// A lone CR or LF.
+			s = s[1:]
+		}
+		records = append(records, trimEmpty(record))
+		record = []string{}
+	}
+	if len(record) > 0 {
+		records = append(records, trimEmpty(record))
+	}
+	for len(records) > 0 && len(records[len(records)-1]) == 0 {
+		records = records[:len(records)-1]
+	}
+	return records
+}
+
+// readField returns the field at the start of s and the rest of s, which is
+// empty or starts with the tab or line break ending the field.
+func readField(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		if field, rest, ok := readQuoted(s); ok {
+			return field, rest
+		}
+	}
+	end := strings.IndexAny(s, "\t\r\n")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// readQuoted reads a quoted field. It fails if the closing quote is missing
+// or isn't followed by the end of the field.
+func readQuoted(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '"' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '"' {
+			b.WriteByte('"')
+			i++
+			continue
+		}
+		rest := s[i+1:]
+		if rest == "" || strings.IndexAny(rest[:1], "\t\r\n") == 0 {
+			return b.String(), rest, true
+		}
+		return "", "", false
+	}
+	return "", "", false
+}
+
+func trimEmpty(record []string) []string {
+	for len(record) > 0 && record[len(record)-1] == "" {
+		record = record[:len(record)-1]
+	}
+	return record
+}
+
+// Write joins records with tabs and CRLF line endings, quoting fields that
+// Read would otherwise split or unquote.
+func Write(records [][]string) []byte {
+	var b strings.Builder
+	for r, record := range records {
+		if r > 0 {
+			b.WriteString("\r\n")
+		}
+		for i, field := range record {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			if needsQuotes(field) {
+				b.WriteByte('"')
+				b.WriteString(strings.ReplaceAll(field, `"`, `""`))
+				b.WriteByte('"')
+			} else {
+				b.WriteString(field)
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+func needsQuotes(field string) bool {
+	return strings.ContainsAny(field, "\t\r\n") || strings.HasPrefix(field, `"`) || strings.HasPrefix(field, bom)
+}
@@ -0,0 +1,79 @@
+package tsv
+
+import (
+	"bytes"
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := map[string][][]string{
+		"name\tsurname\r\nAda\tJones\r\n":  {{"name", "surname"}, {"Ada", "Jones"}},
+		"name\tsurname\nAda\tJones\n":      {{"name", "surname"}, {"Ada", "Jones"}},
+		"name\tsurname\rAda\tJones":        {{"name", "surname"}, {"Ada", "Jones"}},
+		"\ufeffname\r\nAda":                {{"name"}, {"Ada"}},
+		"name\tsurname\t\t\r\nAda\t\t\r\n": {{"name", "surname"}, {"Ada"}},
+		"name\r\n\r\nAda\r\n\r\n\r\n":      {{"name"}, {}, {"Ada"}},
+		"name\tsurname\r\n\tJones":         {{"name", "surname"}, {"", "Jones"}},
+		"title\r\n\"The\tGreat\"\r\n":      {{"title"}, {"The\tGreat"}},
+		"title\r\n\"The\r\nGreat\"\tx":     {{"title"}, {"The\r\nGreat", "x"}},
+		"title\r\n\"Say \"\"Hi\"\"\"":      {{"title"}, {`Say "Hi"`}},
+		"title\r\n\"Big\" Sam\r\n":         {{"title"}, {`"Big" Sam`}},
+		"title\r\n\"Unclosed\tfield\r\n":   {{"title"}, {`"Unclosed`, "field"}},
+		"title\r\nO\"Brien\r\n":            {{"title"}, {`O"Brien`}},
+		"":                                 {},
+	}
+	for input, expected := range tests {
+		if got := Read([]byte(input)); !cmp.Equal(got, expected) {
+			t.Errorf("Read(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	records := [][]string{{"name", "title"}, {"Ada", "The\tGreat"}, {"", `"Big" Sam`}, {"O\"Brien"}}
+	expected := "name\ttitle\r\nAda\t\"The\tGreat\"\r\n\t\"\"\"Big\"\" Sam\"\r\nO\"Brien"
+	if got := string(Write(records)); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got := Read(Write(records)); !cmp.Equal(got, records) {
+		t.Errorf("Expected %q to round-trip, got %q", records, got)
+	}
+}
+
+func TestRoundTrip_namesTsv(t *testing.T) {
+	contents, err := os.ReadFile("../names.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := Read(contents)
+	if len(records) < 2 || len(records[0]) < 2 {
+		t.Fatalf("Expected a header and rows, got %d records", len(records))
+	}
+
+	for name, variant := range map[string][]byte{
+		"LF":   bytes.ReplaceAll(contents, []byte("\r\n"), []byte("\n")),
+		"CRLF": bytes.ReplaceAll(bytes.ReplaceAll(contents, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n")),
+		"BOM":  append([]byte("\ufeff"), contents...),
+	} {
+		if got := Read(variant); !cmp.Equal(got, records) {
+			t.Errorf("Expected the %s variant to read the same records", name)
+		}
+	}
+
+	written := Write(records)
+	if got := Read(written); !cmp.Equal(got, records) {
+		t.Errorf("Expected names.tsv to round-trip")
+	}
+	canonical := strings.TrimRight(strings.ReplaceAll(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n", "\r\n"), "\r\n")
+	if string(written) != canonical {
+		t.Errorf("Expected writing names.tsv to reproduce it with CRLF line endings")
+	}
+	for i, record := range records[1:] {
+		if len(record) > len(records[0]) {
+			t.Errorf("Row %d has %d fields but there are only %d columns", i+2, len(record), len(records[0]))
+		}
+	}
+}
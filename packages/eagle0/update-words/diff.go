@@ -0,0 +1,105 @@
+package main
+
+import (
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
+	"sort"
+)
+
+type columnDiff struct {
+	Column  string   `json:"column"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Before  int      `json:"before"`
+	After   int      `json:"after"`
+}
+
+type diffReport struct {
+	AddedColumns   []string     `json:"addedColumns,omitempty"`
+	RemovedColumns []string     `json:"removedColumns,omitempty"`
+	Columns        []columnDiff `json:"columns,omitempty"`
+	// TemplateChanged is set when the template differs from the published one.
+	TemplateChanged bool `json:"templateChanged,omitempty"`
+}
+
+// tsvColumns returns the set of entries in each column of a condensed TSV.
+func tsvColumns(sheet string) map[string]map[string]bool {
+	columns := map[string]map[string]bool{}
+	records := tsv.Read([]byte(sheet))
+	if len(records) == 0 {
+		return columns
+	}
+
+	headers := records[0]
+	for _, h := range headers {
+		columns[h] = map[string]bool{}
+	}
+	for _, values := range records[1:] {
+		for i, value := range values {
+			if i < len(headers) && value != "" {
+				columns[headers[i]][value] = true
+			}
+		}
+	}
+	return columns
+}
+
+// diffTsv reports, column by column, how the entries of the new word lists
+// differ from the published ones. Columns without changes are omitted.
+func diffTsv(published string, updated string) *diffReport {
+	before := tsvColumns(published)
+	after := tsvColumns(updated)
+	report := &diffReport{}
+
+	for _, column := range sortedKeys(after) {
+		if _, ok := before[column]; !ok {
+			report.AddedColumns = append(report.AddedColumns, column)
+		}
+	}
+	for _, column := range sortedKeys(before) {
+		if _, ok := after[column]; !ok {
+			report.RemovedColumns = append(report.RemovedColumns, column)
+		}
+	}
+
+	all := map[string]bool{}
+	for column := range before {
+		all[column] = true
+	}
+	for column := range after {
+		all[column] = true
+	}
+	for _, column := range sortedKeys(all) {
+		diff := columnDiff{
+			Column:  column,
+			Added:   missingFrom(after[column], before[column]),
+			Removed: missingFrom(before[column], after[column]),
+			Before:  len(before[column]),
+			After:   len(after[column]),
+		}
+		if len(diff.Added) > 0 || len(diff.Removed) > 0 {
+			report.Columns = append(report.Columns, diff)
+		}
+	}
+	return report
+}
+
+// missingFrom returns the sorted entries of set that are not in other.
+func missingFrom(set map[string]bool, other map[string]bool) []string {
+	missing := []string{}
+	for entry := range set {
+		if !other[entry] {
+			missing = append(missing, entry)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestDiffTsv(t *testing.T) {
+	published := "name\tsurname\ttitle\r\nAda\tJones\tDuke\r\nBob\tSmith"
+	updated := "name\tsurname\tplace\r\nAda\tJones\tRome\r\nCarl\tSmith"
+
+	report := diffTsv(published, updated)
+	expected := &diffReport{
+		AddedColumns:   []string{"place"},
+		RemovedColumns: []string{"title"},
+		Columns: []columnDiff{
+			{Column: "name", Added: []string{"Carl"}, Removed: []string{"Bob"}, Before: 2, After: 2},
+			{Column: "place", Added: []string{"Rome"}, Removed: []string{}, Before: 0, After: 1},
+			{Column: "title", Added: []string{}, Removed: []string{"Duke"}, Before: 1, After: 0},
+		},
+	}
+	if diff := cmp.Diff(expected, report); diff != "" {
+		t.Errorf("Unexpected report (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffTsv_nothingPublished(t *testing.T) {
+	report := diffTsv("", "name\r\nAda")
+	if len(report.AddedColumns) != 1 || len(report.Columns) != 1 || report.Columns[0].After != 1 {
+		t.Errorf("Expected everything to be added, got %+v", report)
+	}
+}
+
+func TestDiffTsv_unchanged(t *testing.T) {
+	tsv := "name\tsurname\r\nAda\tJones"
+	report := diffTsv(tsv, tsv)
+	if len(report.AddedColumns)+len(report.RemovedColumns)+len(report.Columns) != 0 {
+		t.Errorf("Expected an empty report, got %+v", report)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sourcesEnvVar names the environment variable holding a JSON sourcesConfig.
+// When it is unset, the single source in sourceEnvVar is used.
+const sourcesEnvVar = "WORDS_SOURCES"
+
+type sourceConfig struct {
+	// Source is a source specification as accepted by parseSource.
+	Source string `json:"source"`
+	// Owns lists the lists this source is authoritative for. Entries for them
+	// from any other source are dropped and reported as conflicts.
+	Owns []string `json:"owns,omitempty"`
+}
+
+type sourcesConfig struct {
+	Sources []sourceConfig `json:"sources"`
+}
+
+// namedSource is a source together with the specification it was built from,
+// which identifies it in reports.
+type namedSource struct {
+	Name   string
+	Source source
+}
+
+// sourceSheet is a sheet fetched from a named source.
+type sourceSheet struct {
+	Source  string
+	Content []byte
+}
+
+// ownership maps list names to the name of the source that owns them. Lists
+// without an owner are the union of every source's entries.
+type ownership map[string]string
+
+type mergeConflict struct {
+	List    string   `json:"list"`
+	Owner   string   `json:"owner"`
+	Source  string   `json:"source"`
+	Dropped []string `json:"dropped"`
+}
+
+func configuredSources() ([]namedSource, ownership, error) {
+	raw := os.Getenv(sourcesEnvVar)
+	if raw == "" {
+		src, err := configuredSource()
+		if err != nil {
+			return nil, nil, err
+		}
+		return []namedSource{{Name: "source", Source: src}}, ownership{}, nil
+	}
+
+	var config sourcesConfig
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", sourcesEnvVar, err)
+	}
+	return config.build()
+}
+
+func (config sourcesConfig) build() ([]namedSource, ownership, error) {
+	if len(config.Sources) == 0 {
+		return nil, nil, fmt.Errorf("no sources configured")
+	}
+
+	sources := []namedSource{}
+	owners := ownership{}
+	for _, sc := range config.Sources {
+		src, err := parseSource(sc.Source)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, list := range sc.Owns {
+			if owner, ok := owners[list]; ok {
+				return nil, nil, fmt.Errorf("list %q is owned by both %s and %s", list, owner, sc.Source)
+			}
+			owners[list] = sc.Source
+		}
+		sources = append(sources, namedSource{Name: sc.Source, Source: src})
+	}
+	return sources, owners, nil
+}
+
+// applyOwnership removes the columns of owned lists from every sheet but the
+// owner's, returning the remaining sheets and a conflict for each source that
+// had entries the owner lacks.
+func applyOwnership(sheets []sourceSheet, owners ownership) ([]string, []mergeConflict) {
+	ownerEntries := map[string]map[string]bool{}
+	for _, sheet := range sheets {
+		for header, entries := range tsvColumns(string(sheet.Content)) {
+			list, _, _ := strings.Cut(header, "@")
+			if owners[list] != sheet.Source {
+				continue
+			}
+			if ownerEntries[list] == nil {
+				ownerEntries[list] = map[string]bool{}
+			}
+			for entry := range entries {
+				ownerEntries[list][strings.TrimSpace(entry)] = true
+			}
+		}
+	}
+
+	tsvs := []string{}
+	conflicts := []mergeConflict{}
+	for _, sheet := range sheets {
+		records := tsv.Read(sheet.Content)
+		if len(records) == 0 {
+			continue
+		}
+		headers := records[0]
+		keep := []int{}
+		dropped := map[string]map[string]bool{}
+		for i, header := range headers {
+			list, _, _ := strings.Cut(header, "@")
+			owner, owned := owners[list]
+			if !owned || owner == sheet.Source {
+				keep = append(keep, i)
+				continue
+			}
+			if dropped[list] == nil {
+				dropped[list] = map[string]bool{}
+			}
+			for _, values := range records[1:] {
+				if i < len(values) {
+					if entry := strings.TrimSpace(values[i]); entry != "" && !ownerEntries[list][entry] {
+						dropped[list][entry] = true
+					}
+				}
+			}
+		}
+
+		for _, list := range sortedKeys(dropped) {
+			if len(dropped[list]) > 0 {
+				conflicts = append(conflicts, mergeConflict{
+					List:    list,
+					Owner:   owners[list],
+					Source:  sheet.Source,
+					Dropped: sortedKeys(dropped[list]),
+				})
+			}
+		}
+		if len(keep) == 0 {
+			continue
+		}
+		keptRecords := make([][]string, len(records))
+		for r, values := range records {
+			kept := make([]string, 0, len(keep))
+			for _, i := range keep {
+				if i < len(values) {
+					kept = append(kept, values[i])
+				} else {
+					kept = append(kept, "")
+				}
+			}
+			keptRecords[r] = kept
+		}
+		tsvs = append(tsvs, string(tsv.Write(keptRecords)))
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].List < conflicts[j].List
+	})
+	return tsvs, conflicts
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestSourcesConfig_build(t *testing.T) {
+	config := sourcesConfig{Sources: []sourceConfig{
+		{Source: "file:/lists/units", Owns: []string{"heavy_infantry"}},
+		{Source: "sheet:abc123", Owns: []string{"name", "surname"}},
+	}}
+
+	sources, owners, err := config.build()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sources) != 2 || sources[1].Name != "sheet:abc123" {
+		t.Errorf("Unexpected sources %+v", sources)
+	}
+	expected := ownership{"heavy_infantry": "file:/lists/units", "name": "sheet:abc123", "surname": "sheet:abc123"}
+	if !cmp.Equal(owners, expected) {
+		t.Errorf("Expected owners %v, got %v", expected, owners)
+	}
+}
+
+func TestSourcesConfig_conflictingOwners(t *testing.T) {
+	config := sourcesConfig{Sources: []sourceConfig{
+		{Source: "file:/lists/a", Owns: []string{"name"}},
+		{Source: "file:/lists/b", Owns: []string{"name"}},
+	}}
+
+	if _, _, err := config.build(); err == nil {
+		t.Errorf("Expected an error for a list with two owners")
+	}
+}
+
+func TestConfiguredSources_fromEnvironment(t *testing.T) {
+	t.Setenv(sourcesEnvVar, `{"sources": [{"source": "file:/lists/a"}, {"source": "file:/lists/b", "owns": ["title"]}]}`)
+
+	sources, owners, err := configuredSources()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sources) != 2 || owners["title"] != "file:/lists/b" {
+		t.Errorf("Unexpected configuration %+v %v", sources, owners)
+	}
+}
+
+func TestApplyOwnership_unionWithoutOwners(t *testing.T) {
+	tsvs, conflicts := applyOwnership(sheets("name\r\nAda", "name\r\nBob"), ownership{})
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %+v", conflicts)
+	}
+	if condensedTsv(tsvs...) != "name\r\nAda\r\nBob" {
+		t.Errorf("Expected a union of both sources, got %q", condensedTsv(tsvs...))
+	}
+}
+
+func TestApplyOwnership_dropsAndReportsOthersEntries(t *testing.T) {
+	input := sheets(
+		"name@female\tsurname\r\nAda\tJones\r\nAgnes\tSmith",
+		"name@female\tname@male\ttitle\r\nAda\tBob\tDuke\r\nZelda\t\t",
+	)
+	tsvs, conflicts := applyOwnership(input, ownership{"name": "source1"})
+
+	expected := []mergeConflict{
+		{List: "name", Owner: "source1", Source: "source2", Dropped: []string{"Bob", "Zelda"}},
+	}
+	if !cmp.Equal(conflicts, expected) {
+		t.Errorf("Expected conflicts %+v, got %+v", expected, conflicts)
+	}
+	merged := condensedTsv(tsvs...)
+	if merged != "name@female\tsurname\ttitle\r\nAda\tJones\tDuke\r\nAgnes\tSmith" {
+		t.Errorf("Unexpected merged sheet %q", merged)
+	}
+}
+
+func TestUpdate_mergesSources(t *testing.T) {
+	store := newMemStore(map[string]string{
+		"nameConstruction.txt": `$name " " $surname`,
+	})
+
+	status, result := update(store, sheets("name\r\nAda\r\n", "surname\r\nJones\r\n"), []byte("$name"), ownership{}, allSteps, true)
+	if status != 200 {
+		t.Fatalf("Expected status 200, got %d: %+v", status, result)
+	}
+	if len(result.Diff.AddedColumns) != 2 {
+		t.Errorf("Expected both columns to be added, got %+v", result.Diff)
+	}
+}
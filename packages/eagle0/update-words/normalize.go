@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// normalizeEnvVar names the environment variable holding a comma-separated
+// list of normalization steps, or "none". When it is unset, every step runs.
+const normalizeEnvVar = "NORMALIZE"
+
+// The normalization steps, in the order they run.
+const (
+	// stepNFC composes accents, so "Ní" typed with a combining accent matches
+	// "Ní" typed with a precomposed one.
+	stepNFC = "nfc"
+	// stepWhitespace trims entries and collapses runs of whitespace, including
+	// non-breaking spaces, to a single space.
+	stepWhitespace = "whitespace"
+	// stepQuotes replaces typographic quotes and apostrophes with ASCII ones.
+	stepQuotes = "quotes"
+	// stepCase merges entries of a list that differ only in case, keeping the
+	// most common form.
+	stepCase = "case"
+)
+
+var allSteps = []string{stepNFC, stepWhitespace, stepQuotes, stepCase}
+
+// entrySteps are the steps that rewrite each entry on its own.
+var entrySteps = map[string]func(string) string{
+	stepNFC:        norm.NFC.String,
+	stepWhitespace: collapseWhitespace,
+	stepQuotes:     normalizeQuotes,
+}
+
+var quoteReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "ʼ", "'",
+	"“", "\"", "”", "\"", "„", "\"", "‟", "\"",
+)
+
+// normalizationChange records that an entry of a list was rewritten by a step.
+type normalizationChange struct {
+	List string `json:"list"`
+	Step string `json:"step"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// configuredSteps returns the normalization steps to run, in pipeline order.
+func configuredSteps() ([]string, error) {
+	raw, ok := os.LookupEnv(normalizeEnvVar)
+	if !ok || strings.TrimSpace(raw) == "" {
+		return allSteps, nil
+	}
+	if strings.TrimSpace(raw) == "none" {
+		return []string{}, nil
+	}
+
+	enabled := map[string]bool{}
+	for _, step := range strings.Split(raw, ",") {
+		step = strings.ToLower(strings.TrimSpace(step))
+		if _, ok := entrySteps[step]; !ok && step != stepCase {
+			return nil, fmt.Errorf("invalid %s: unknown step %q", normalizeEnvVar, step)
+		}
+		enabled[step] = true
+	}
+	steps := []string{}
+	for _, step := range allSteps {
+		if enabled[step] {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// normalizeSheets runs the normalization steps over every entry of every
+// sheet. Headers are left alone. It returns the rewritten sheets and what was
+// changed, ordered by list and entry.
+func normalizeSheets(sheets []sourceSheet, steps []string) ([]sourceSheet, []normalizationChange) {
+	changes := map[normalizationChange]bool{}
+	normalized := make([]sourceSheet, len(sheets))
+	// forms counts the occurrences of each form of an entry, keyed by list and
+	// case-folded entry, for the case step.
+	forms := map[string]map[string]map[string]int{}
+	firstSeen := map[string]int{}
+	fold := cases.Fold()
+
+	sheetRecords := make([][][]string, len(sheets))
+	for s, sheet := range sheets {
+		records := tsv.Read(sheet.Content)
+		sheetRecords[s] = records
+		if len(records) == 0 {
+			continue
+		}
+		headers := records[0]
+		for _, values := range records[1:] {
+			for i, value := range values {
+				if i >= len(headers) {
+					break
+				}
+				for _, step := range steps {
+					apply, ok := entrySteps[step]
+					if !ok {
+						continue
+					}
+					if next := apply(value); next != value {
+						changes[normalizationChange{List: headers[i], Step: step, From: value, To: next}] = true
+						value = next
+					}
+				}
+				values[i] = value
+				if value == "" {
+					continue
+				}
+				folded := fold.String(value)
+				if forms[headers[i]] == nil {
+					forms[headers[i]] = map[string]map[string]int{}
+				}
+				if forms[headers[i]][folded] == nil {
+					forms[headers[i]][folded] = map[string]int{}
+				}
+				forms[headers[i]][folded][value]++
+				if _, ok := firstSeen[value]; !ok {
+					firstSeen[value] = len(firstSeen)
+				}
+			}
+		}
+	}
+
+	for s, records := range sheetRecords {
+		if contains(steps, stepCase) && len(records) > 0 {
+			headers := records[0]
+			for _, values := range records[1:] {
+				for i, value := range values {
+					if i >= len(headers) || value == "" {
+						continue
+					}
+					canonical := canonicalForm(forms[headers[i]][fold.String(value)], firstSeen)
+					if canonical != value {
+						changes[normalizationChange{List: headers[i], Step: stepCase, From: value, To: canonical}] = true
+						values[i] = canonical
+					}
+				}
+			}
+		}
+		normalized[s] = sourceSheet{Source: sheets[s].Source, Content: tsv.Write(records)}
+	}
+
+	report := make([]normalizationChange, 0, len(changes))
+	for change := range changes {
+		report = append(report, change)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i], report[j]
+		if a.List != b.List {
+			return a.List < b.List
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.Step < b.Step
+	})
+	return normalized, report
+}
+
+// canonicalForm picks the form of an entry to keep: the one used most often,
+// or the first one seen if there is a tie.
+func canonicalForm(counts map[string]int, firstSeen map[string]int) string {
+	best := ""
+	for form, count := range counts {
+		if best == "" || count > counts[best] || (count == counts[best] && firstSeen[form] < firstSeen[best]) {
+			best = form
+		}
+	}
+	return best
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+}
+
+func normalizeQuotes(s string) string {
+	return quoteReplacer.Replace(s)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
+	"testing"
+)
+
+func TestNormalizeSheets_entrySteps(t *testing.T) {
+	sheet := "name\tsurname\r\n" +
+		"Ní\tOğlu\r\n" + // combining accents
+		"  Dear  Leader \t‘Ashes’\r\n" +
+		"“Big” Sam\t"
+	normalized, changes := normalizeSheets(sheets(sheet), []string{stepNFC, stepWhitespace, stepQuotes})
+
+	expected := [][]string{
+		{"name", "surname"},
+		{"Ní", "Oğlu"},
+		{"Dear Leader", "'Ashes'"},
+		{`"Big" Sam`},
+	}
+	if got := tsv.Read(normalized[0].Content); !cmp.Equal(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if len(changes) != 5 {
+		t.Errorf("Expected 5 changes, got %+v", changes)
+	}
+	if changes[0].List != "name" || changes[0].Step != stepWhitespace || changes[0].To != "Dear Leader" {
+		t.Errorf("Expected changes ordered by list and entry, got %+v", changes)
+	}
+}
+
+func TestNormalizeSheets_caseKeepsMostCommonForm(t *testing.T) {
+	normalized, changes := normalizeSheets(
+		sheets("title\r\nDear Leader\r\ndear Leader", "title\r\nDear Leader\r\nthe Duke", "title@male\r\ndear Leader"),
+		allSteps,
+	)
+
+	if got := condensedTsv(string(normalized[0].Content), string(normalized[1].Content)); got != "title\r\nDear Leader\r\nthe Duke" {
+		t.Errorf("Expected the case variants to merge, got %q", got)
+	}
+	// Columns are deduplicated separately, as each bucket is its own list.
+	if string(normalized[2].Content) != "title@male\r\ndear Leader" {
+		t.Errorf("Expected other columns to be left alone, got %q", normalized[2].Content)
+	}
+	expected := []normalizationChange{{List: "title", Step: stepCase, From: "dear Leader", To: "Dear Leader"}}
+	if !cmp.Equal(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+}
+
+func TestNormalizeSheets_caseTieKeepsFirstForm(t *testing.T) {
+	normalized, _ := normalizeSheets(sheets("name\r\nmacKenzie\r\nMacKenzie"), allSteps)
+	if string(normalized[0].Content) != "name\r\nmacKenzie\r\nmacKenzie" {
+		t.Errorf("Expected the first form to be kept, got %q", normalized[0].Content)
+	}
+}
+
+func TestNormalizeSheets_noSteps(t *testing.T) {
+	sheet := "name\r\n Ada \r\nada"
+	normalized, changes := normalizeSheets(sheets(sheet), []string{})
+	if string(normalized[0].Content) != sheet || len(changes) != 0 {
+		t.Errorf("Expected nothing to change, got %q %+v", normalized[0].Content, changes)
+	}
+}
+
+func TestConfiguredSteps(t *testing.T) {
+	tests := map[string][]string{
+		"":                allSteps,
+		"none":            {},
+		"case, nfc":       {stepNFC, stepCase},
+		"QUOTES":          {stepQuotes},
+		"whitespace,case": {stepWhitespace, stepCase},
+	}
+	for value, expected := range tests {
+		t.Setenv(normalizeEnvVar, value)
+		steps, err := configuredSteps()
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", value, err)
+		}
+		if !cmp.Equal(steps, expected) {
+			t.Errorf("Expected %v for %q, got %v", expected, value, steps)
+		}
+	}
+
+	t.Setenv(normalizeEnvVar, "nfc,stem")
+	if _, err := configuredSteps(); err == nil {
+		t.Errorf("Expected an error for an unknown step")
+	}
+}
+
+func TestUpdate_reportsNormalization(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	status, result := update(store, sheets("name\r\nAda\r\nada\r\nAda"), []byte("$name"), ownership{}, allSteps, true)
+	if status != 200 {
+		t.Fatalf("Expected status 200, got %d: %+v", status, result)
+	}
+	if len(result.Normalization) != 1 || result.Normalization[0].From != "ada" {
+		t.Errorf("Expected 'ada' to be merged into 'Ada', got %+v", result.Normalization)
+	}
+	if result.Diff.Columns[0].After != 1 {
+		t.Errorf("Expected a single entry after normalization, got %+v", result.Diff)
+	}
+}
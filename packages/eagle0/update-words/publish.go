@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/loader"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/release"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// retainEnvVar sets how many releases are kept in the bucket. Older releases
+// are deleted after each publish.
+const retainEnvVar = "RETAIN_RELEASES"
+
+const defaultRetainedReleases = 10
+
+var releaseFiles = []string{release.NamesTsv, release.NameConstruction}
+
+func retainedReleases() int {
+	value := strings.TrimSpace(os.Getenv(retainEnvVar))
+	if value == "" {
+		return defaultRetainedReleases
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		fmt.Printf("Ignoring invalid %s %q\n", retainEnvVar, value)
+		return defaultRetainedReleases
+	}
+	return n
+}
+
+// currentFile returns the named file of the current release, or the
+// unversioned file if nothing has been released yet.
+func currentFile(store objectStore, name string) ([]byte, error) {
+	id, err := currentRelease(store)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return store.Get(name)
+	}
+	return store.Get(release.Key(id, name))
+}
+
+// currentRelease returns the ID the pointer names, or "" if nothing has been
+// released yet.
+func currentRelease(store objectStore) (string, error) {
+	pointer, err := store.Get(release.CurrentKey)
+	if errors.Is(err, errNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	id, ok := release.ParsePointer(pointer)
+	if !ok {
+		return "", fmt.Errorf("invalid release pointer %q", pointer)
+	}
+	return id, nil
+}
+
+// history returns the published releases, newest first. The current release
+// is listed first if the history lacks it, either because the history update
+// after publishing it failed or because it was published before the history
+// existed.
+func history(store objectStore, current string) ([]release.HistoryEntry, error) {
+	entries := []release.HistoryEntry{}
+	contents, err := store.Get(release.HistoryKey)
+	if err == nil {
+		entries = release.ParseHistory(contents)
+	} else if !errors.Is(err, errNotFound) {
+		return nil, err
+	}
+	if current != "" && indexOf(entries, current) < 0 {
+		entries = append([]release.HistoryEntry{{ID: current}}, entries...)
+	}
+	return entries, nil
+}
+
+// publish stores the word lists together with the template as a new
+// immutable release, checks that it reads back and loads, and only then
+// points the current release at it. Releases beyond the newest retain are
+// deleted. It returns the new release and the one it replaced.
+func publish(store objectStore, namesTsv []byte, template []byte, retain int) (string, string, error) {
+	id := release.ID(namesTsv, template)
+	previous, err := currentRelease(store)
+	if err != nil {
+		return "", "", err
+	}
+	entries, err := history(store, previous)
+	if err != nil {
+		return "", previous, err
+	}
+	// Identical contents published earlier share this ID, so only an upload
+	// nothing else refers to is cleaned up if it isn't published.
+	uploaded := id != previous && indexOf(entries, id) < 0
+
+	if err := store.Put(release.Key(id, release.NamesTsv), namesTsv); err != nil {
+		return "", previous, err
+	}
+	if err := store.Put(release.Key(id, release.NameConstruction), template); err != nil {
+		return "", previous, err
+	}
+	if err := verifyRelease(store, id); err != nil {
+		if uploaded {
+			deleteRelease(store, id)
+		}
+		return "", previous, fmt.Errorf("release %s failed verification: %w", id, err)
+	}
+
+	// Releases listed before the current one were rolled back from by a
+	// rollback whose history update failed.
+	updated := entries
+	if position := indexOf(entries, previous); position > 0 {
+		updated = abandon(entries, position)
+	}
+	updated = append([]release.HistoryEntry{{ID: id}}, without(updated, id)...)
+	stale := []release.HistoryEntry{}
+	if len(updated) > retain {
+		stale = updated[retain:]
+		updated = updated[:retain]
+	}
+
+	// The pointer moves before the history is written, so the history never
+	// lists a release that was never current. A history that lacks the
+	// current release is tolerated by rollback.
+	if err := store.Put(release.CurrentKey, []byte(id+"\n")); err != nil {
+		if uploaded {
+			deleteRelease(store, id)
+		}
+		return "", previous, err
+	}
+	if err := store.Put(release.HistoryKey, release.FormatHistory(updated)); err != nil {
+		fmt.Println("Error updating release history:", err)
+		return id, previous, nil
+	}
+	for _, old := range stale {
+		deleteRelease(store, old.ID)
+	}
+	return id, previous, nil
+}
+
+// rollback points the current release at the one published before it. Rolling
+// back repeatedly walks further back through the retained releases. The
+// release rolled back from is abandoned: it stays in the history and the
+// bucket until it ages out, but no later rollback returns to it.
+func rollback(store objectStore) (int, updateResult) {
+	current, err := currentRelease(store)
+	if err != nil {
+		fmt.Println("Error fetching current release:", err)
+		return 500, updateResult{Error: err.Error()}
+	}
+	if current == "" {
+		return 409, updateResult{Error: "nothing has been released"}
+	}
+	entries, err := history(store, current)
+	if err != nil {
+		fmt.Println("Error fetching release history:", err)
+		return 500, updateResult{Error: err.Error()}
+	}
+
+	position := indexOf(entries, current)
+	target := ""
+	for _, entry := range entries[position+1:] {
+		if !entry.Abandoned {
+			target = entry.ID
+			break
+		}
+	}
+	if target == "" {
+		return 409, updateResult{Error: fmt.Sprintf("no release before %s", current), Release: current}
+	}
+	if err := verifyRelease(store, target); err != nil {
+		fmt.Println("Refusing to roll back:", err)
+		return 500, updateResult{Error: fmt.Sprintf("release %s failed verification: %v", target, err), Release: current}
+	}
+	if err := store.Put(release.CurrentKey, []byte(target+"\n")); err != nil {
+		fmt.Println("Error moving release pointer:", err)
+		return 500, updateResult{Error: err.Error(), Release: current}
+	}
+	fmt.Println("Rolled back from", current, "to", target)
+
+	if err := store.Put(release.HistoryKey, release.FormatHistory(abandon(entries, position+1))); err != nil {
+		// The next publish abandons whatever the history lists before the
+		// current release.
+		fmt.Println("Error updating release history:", err)
+	}
+	return 200, updateResult{Release: target, Previous: current}
+}
+
+// verifyRelease reads a release back and checks that its contents match its
+// ID and that it loads the way the names function will load it.
+func verifyRelease(store objectStore, id string) error {
+	namesTsv, err := store.Get(release.Key(id, release.NamesTsv))
+	if err != nil {
+		return err
+	}
+	template, err := store.Get(release.Key(id, release.NameConstruction))
+	if err != nil {
+		return err
+	}
+	if got := release.ID(namesTsv, template); got != id {
+		return fmt.Errorf("contents read back as release %s", got)
+	}
+	data, err := loader.Load(namesTsv, template)
+	if err != nil {
+		return err
+	}
+	if missing := data.MissingLists(); len(missing) > 0 {
+		return fmt.Errorf("template uses undefined lists %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func deleteRelease(store objectStore, id string) {
+	for _, file := range releaseFiles {
+		if err := store.Delete(release.Key(id, file)); err != nil && !errors.Is(err, errNotFound) {
+			fmt.Println("Error deleting release file:", err)
+		}
+	}
+}
+
+func indexOf(entries []release.HistoryEntry, id string) int {
+	for i := range entries {
+		if entries[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func without(entries []release.HistoryEntry, id string) []release.HistoryEntry {
+	kept := make([]release.HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ID != id {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// abandon returns a copy of entries with the first n marked as abandoned.
+func abandon(entries []release.HistoryEntry, n int) []release.HistoryEntry {
+	marked := append([]release.HistoryEntry{}, entries...)
+	for i := 0; i < n; i++ {
+		marked[i].Abandoned = true
+	}
+	return marked
+}
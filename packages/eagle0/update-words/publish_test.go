@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/release"
+	"testing"
+)
+
+// corruptingStore stores a different body than it was given for one key, the
+// way a truncated upload would.
+type corruptingStore struct {
+	*memStore
+	key string
+}
+
+func (s corruptingStore) Put(key string, body []byte) error {
+	if key == s.key {
+		body = body[:len(body)/2]
+	}
+	return s.memStore.Put(key, body)
+}
+
+// failingStore fails to store one key.
+type failingStore struct {
+	*memStore
+	key string
+}
+
+func (s failingStore) Put(key string, body []byte) error {
+	if key == s.key {
+		return errors.New("put failed")
+	}
+	return s.memStore.Put(key, body)
+}
+
+func publishAll(t *testing.T, store objectStore, retain int, tsvs ...string) []string {
+	ids := []string{}
+	for _, tsv := range tsvs {
+		id, _, err := publish(store, []byte(tsv), []byte("$name"), retain)
+		if err != nil {
+			t.Fatalf("Expected no error publishing %q, got %v", tsv, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func currentID(t *testing.T, store objectStore) string {
+	id, err := currentRelease(store)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return id
+}
+
+func TestPublish_recordsHistoryAndPrevious(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	ids := publishAll(t, store, defaultRetainedReleases, "name\r\nAda", "name\r\nBob")
+
+	history := release.ParseHistory(store.objects[release.HistoryKey])
+	if len(history) != 2 || history[0].ID != ids[1] || history[1].ID != ids[0] {
+		t.Errorf("Expected history [%s %s], got %v", ids[1], ids[0], history)
+	}
+
+	_, previous, err := publish(store, []byte("name\r\nCy"), []byte("$name"), defaultRetainedReleases)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if previous != ids[1] {
+		t.Errorf("Expected previous release %s, got '%s'", ids[1], previous)
+	}
+}
+
+func TestPublish_doesNotMovePointerWhenReadBackDiffers(t *testing.T) {
+	mem := newMemStore(map[string]string{})
+	first := publishAll(t, mem, defaultRetainedReleases, "name\r\nAda")[0]
+
+	id := release.ID([]byte("name\r\nBobby"), []byte("$name"))
+	store := corruptingStore{memStore: mem, key: release.Key(id, release.NamesTsv)}
+	if _, _, err := publish(store, []byte("name\r\nBobby"), []byte("$name"), defaultRetainedReleases); err == nil {
+		t.Fatalf("Expected an error for a corrupted upload")
+	}
+
+	if current := currentID(t, mem); current != first {
+		t.Errorf("Expected the pointer to stay at %s, got %s", first, current)
+	}
+	if _, ok := mem.objects[release.Key(id, release.NamesTsv)]; ok {
+		t.Errorf("Expected the unverified upload to be removed")
+	}
+}
+
+func TestPublish_doesNotMovePointerWhenReleaseDoesNotLoad(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	first := publishAll(t, store, defaultRetainedReleases, "name\r\nAda")[0]
+
+	if _, _, err := publish(store, []byte("name\r\nBob"), []byte(`$name " " $surname`), defaultRetainedReleases); err == nil {
+		t.Fatalf("Expected an error for a template using a missing list")
+	}
+	if current := currentID(t, store); current != first {
+		t.Errorf("Expected the pointer to stay at %s, got %s", first, current)
+	}
+}
+
+func TestPublish_prunesOldReleases(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	ids := publishAll(t, store, 2, "name\r\nAda", "name\r\nBob", "name\r\nCy")
+
+	if _, ok := store.objects[release.Key(ids[0], release.NamesTsv)]; ok {
+		t.Errorf("Expected the oldest release to be deleted")
+	}
+	for _, id := range ids[1:] {
+		if _, ok := store.objects[release.Key(id, release.NamesTsv)]; !ok {
+			t.Errorf("Expected release %s to be retained", id)
+		}
+	}
+	if history := release.ParseHistory(store.objects[release.HistoryKey]); len(history) != 2 {
+		t.Errorf("Expected two releases in the history, got %v", history)
+	}
+}
+
+func TestPublish_republishingMovesReleaseToFront(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	ids := publishAll(t, store, defaultRetainedReleases, "name\r\nAda", "name\r\nBob", "name\r\nAda")
+
+	history := release.ParseHistory(store.objects[release.HistoryKey])
+	if len(history) != 2 || history[0].ID != ids[0] || history[1].ID != ids[1] {
+		t.Errorf("Expected history [%s %s], got %v", ids[0], ids[1], history)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	ids := publishAll(t, store, defaultRetainedReleases, "name\r\nAda", "name\r\nBob", "name\r\nCy")
+
+	status, result := rollback(store)
+	if status != 200 || result.Release != ids[1] || result.Previous != ids[2] {
+		t.Errorf("Expected a rollback from %s to %s, got %d %+v", ids[2], ids[1], status, result)
+	}
+	status, result = rollback(store)
+	if status != 200 || result.Release != ids[0] {
+		t.Errorf("Expected a second rollback to %s, got %d %+v", ids[0], status, result)
+	}
+	if status, _ := rollback(store); status != 409 {
+		t.Errorf("Expected 409 with nothing left to roll back to, got %d", status)
+	}
+	if current := currentID(t, store); current != ids[0] {
+		t.Errorf("Expected the pointer at %s, got %s", ids[0], current)
+	}
+}
+
+func TestRollback_abandonsRelease(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	ids := publishAll(t, store, defaultRetainedReleases, "name\r\nAda", "name\r\nBob")
+	if status, _ := rollback(store); status != 200 {
+		t.Fatalf("Expected a rollback, got %d", status)
+	}
+	if err := verifyRelease(store, ids[1]); err != nil {
+		t.Errorf("Expected the abandoned release to still load, got %v", err)
+	}
+
+	cy := publishAll(t, store, defaultRetainedReleases, "name\r\nCy")[0]
+	history := release.ParseHistory(store.objects[release.HistoryKey])
+	expected := []release.HistoryEntry{{ID: cy}, {ID: ids[1], Abandoned: true}, {ID: ids[0]}}
+	if len(history) != 3 || history[0] != expected[0] || history[1] != expected[1] || history[2] != expected[2] {
+		t.Errorf("Expected history %v, got %v", expected, history)
+	}
+	if status, result := rollback(store); status != 200 || result.Release != ids[0] {
+		t.Errorf("Expected a rollback to %s rather than the abandoned %s, got %d %+v", ids[0], ids[1], status, result)
+	}
+}
+
+func TestPublish_prunesAbandonedReleases(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	ids := publishAll(t, store, 2, "name\r\nAda", "name\r\nBob")
+	if status, _ := rollback(store); status != 200 {
+		t.Fatalf("Expected a rollback, got %d", status)
+	}
+	publishAll(t, store, 2, "name\r\nCy", "name\r\nDan")
+
+	for _, id := range ids {
+		if _, ok := store.objects[release.Key(id, release.NamesTsv)]; ok {
+			t.Errorf("Expected release %s to age out", id)
+		}
+	}
+}
+
+func TestPublish_movesPointerBeforeHistory(t *testing.T) {
+	mem := newMemStore(map[string]string{})
+	first := publishAll(t, mem, defaultRetainedReleases, "name\r\nAda")[0]
+
+	store := failingStore{memStore: mem, key: release.CurrentKey}
+	if _, _, err := publish(store, []byte("name\r\nBob"), []byte("$name"), defaultRetainedReleases); err == nil {
+		t.Fatalf("Expected an error when the pointer can't be moved")
+	}
+	if history := release.ParseHistory(mem.objects[release.HistoryKey]); len(history) != 1 || history[0].ID != first {
+		t.Errorf("Expected the history to list only %s, got %v", first, history)
+	}
+	bob := release.ID([]byte("name\r\nBob"), []byte("$name"))
+	if _, ok := mem.objects[release.Key(bob, release.NamesTsv)]; ok {
+		t.Errorf("Expected the unpublished upload to be removed")
+	}
+
+	store = failingStore{memStore: mem, key: release.HistoryKey}
+	second, _, err := publish(store, []byte("name\r\nBob"), []byte("$name"), defaultRetainedReleases)
+	if err != nil {
+		t.Fatalf("Expected the release to be published without its history, got %v", err)
+	}
+	if current := currentID(t, mem); current != second {
+		t.Errorf("Expected the pointer at %s, got %s", second, current)
+	}
+	if status, result := rollback(mem); status != 200 || result.Release != first {
+		t.Errorf("Expected a rollback to %s despite the missing history entry, got %d %+v", first, status, result)
+	}
+}
+
+func TestPublish_abandonsReleasesRolledBackWithoutHistory(t *testing.T) {
+	mem := newMemStore(map[string]string{})
+	ids := publishAll(t, mem, defaultRetainedReleases, "name\r\nAda", "name\r\nBob", "name\r\nCy")
+
+	if status, _ := rollback(failingStore{memStore: mem, key: release.HistoryKey}); status != 200 {
+		t.Fatalf("Expected the rollback to succeed without its history, got %d", status)
+	}
+	dan := publishAll(t, mem, defaultRetainedReleases, "name\r\nDan")[0]
+	history := release.ParseHistory(mem.objects[release.HistoryKey])
+	expected := []release.HistoryEntry{{ID: dan}, {ID: ids[2], Abandoned: true}, {ID: ids[1]}, {ID: ids[0]}}
+	if len(history) != 4 || history[0] != expected[0] || history[1] != expected[1] || history[2] != expected[2] || history[3] != expected[3] {
+		t.Errorf("Expected history %v, got %v", expected, history)
+	}
+	if err := verifyRelease(mem, ids[2]); err != nil {
+		t.Errorf("Expected the abandoned release to still load, got %v", err)
+	}
+	if status, result := rollback(mem); status != 200 || result.Release != ids[1] {
+		t.Errorf("Expected a rollback to %s rather than the abandoned %s, got %d %+v", ids[1], ids[2], status, result)
+	}
+}
+
+func TestRollback_nothingReleased(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	if status, _ := rollback(store); status != 409 {
+		t.Errorf("Expected 409, got %d", status)
+	}
+}
+
+func TestRollback_refusesMissingRelease(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	ids := publishAll(t, store, defaultRetainedReleases, "name\r\nAda", "name\r\nBob")
+	delete(store.objects, release.Key(ids[0], release.NamesTsv))
+
+	if status, _ := rollback(store); status != 500 {
+		t.Errorf("Expected 500, got %d", status)
+	}
+	if current := currentID(t, store); current != ids[1] {
+		t.Errorf("Expected the pointer to stay at %s, got %s", ids[1], current)
+	}
+}
+
+func TestRetainedReleases(t *testing.T) {
+	t.Setenv(retainEnvVar, "3")
+	if n := retainedReleases(); n != 3 {
+		t.Errorf("Expected 3, got %d", n)
+	}
+	t.Setenv(retainEnvVar, "zero")
+	if n := retainedReleases(); n != defaultRetainedReleases {
+		t.Errorf("Expected the default for an invalid value, got %d", n)
+	}
+}
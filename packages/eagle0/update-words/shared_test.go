@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The function is deployed from this directory alone, so the packages it
+// shares with the names function are copied into names/ rather than required
+// from ../names. TestSharedCopy keeps the copy in step; after changing any of
+// these packages, run
+//
+//	UPDATE_SHARED=1 go test -run TestSharedCopy
+const (
+	sharedSource = "../names"
+	sharedCopy   = "names"
+	namesModule  = `"github.com/nolen777/name-generator/packages/eagle0/names/`
+	copyModule   = `"github.com/nolen777/name-generator/packages/eagle0/update-words/names/`
+)
+
+// sharedPackages are the packages of the names module that update-words
+// uses, directly or through another shared package.
+var sharedPackages = []string{"lexicon", "loader", "parser", "release", "token", "tsv"}
+
+// sharedFiles returns the non-test Go files of a package in dir.
+func sharedFiles(t *testing.T, dir string) []string {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{}
+	for _, path := range paths {
+		if name := filepath.Base(path); !strings.HasSuffix(name, "_test.go") {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// copied returns the contents a shared file should have in the copy.
+func copied(pkg string, file string, source []byte) []byte {
+	header := "// Code generated by TestSharedCopy from packages/eagle0/names/" + pkg + "/" + file + ". DO NOT EDIT.\n\n"
+	return append([]byte(header), bytes.ReplaceAll(source, []byte(namesModule), []byte(copyModule))...)
+}
+
+func TestSharedCopy(t *testing.T) {
+	if _, err := os.Stat(sharedSource); err != nil {
+		t.Skipf("the names module isn't checked out next to update-words: %v", err)
+	}
+	update := os.Getenv("UPDATE_SHARED") != ""
+	for _, pkg := range sharedPackages {
+		sourceDir := filepath.Join(sharedSource, pkg)
+		copyDir := filepath.Join(sharedCopy, pkg)
+		sourceFiles := sharedFiles(t, sourceDir)
+
+		if update {
+			if err := os.RemoveAll(copyDir); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(copyDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, file := range sourceFiles {
+			source, err := os.ReadFile(filepath.Join(sourceDir, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := copied(pkg, file, source)
+			path := filepath.Join(copyDir, file)
+			if update {
+				if err := os.WriteFile(path, expected, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			got, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(got, expected) {
+				t.Errorf("%s is out of date with %s; run UPDATE_SHARED=1 go test -run TestSharedCopy", path, filepath.Join(sourceDir, file))
+			}
+		}
+		if extra := len(sharedFiles(t, copyDir)) - len(sourceFiles); extra > 0 {
+			t.Errorf("%s has %d files that %s doesn't; run UPDATE_SHARED=1 go test -run TestSharedCopy", copyDir, extra, sourceDir)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/lexicon"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultSource is the spreadsheet the word lists have always been edited in.
+const defaultSource = "sheet:1DHEsiv4cY4gE6AX3sVH82K__mpBD1aznIYCQwQxA_F0#gid=0"
+
+// sourceEnvVar names the environment variable that overrides defaultSource.
+const sourceEnvVar = "WORDS_SOURCE"
+
+// source produces a sheet: a CRLF-separated TSV whose first row holds the
+// column headers and whose columns hold the entries of each list.
+type source interface {
+	Fetch(ctx context.Context) ([]byte, error)
+}
+
+// parseSource builds a source from a specification, which is one of
+//
+//	https://host/words.tsv           a TSV or CSV file served over HTTP(S)
+//	sheet:<spreadsheet id>#gid=<n>   a Google Sheets tab, exported as TSV
+//	file:/path/to/words.tsv          a local TSV or CSV file
+//	file:/path/to/words              a directory of per-list text files
+//	git:/path/to/checkout?ref=main&path=words
+//	                                 a file or directory in a git checkout
+//
+// A plain path is treated like a file: specification.
+func parseSource(spec string) (source, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source %q: %w", spec, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return httpSource{URL: spec}, nil
+	case "sheet":
+		gid := "0"
+		if fragment, err := url.ParseQuery(u.Fragment); err == nil && fragment.Get("gid") != "" {
+			gid = fragment.Get("gid")
+		}
+		if u.Opaque == "" {
+			return nil, fmt.Errorf("invalid source %q: missing spreadsheet id", spec)
+		}
+		return sheetSource(u.Opaque, gid), nil
+	case "file", "":
+		p := u.Path
+		if u.Opaque != "" {
+			p = u.Opaque
+		}
+		if p == "" {
+			return nil, fmt.Errorf("invalid source %q: missing path", spec)
+		}
+		return fileSource{Path: p}, nil
+	case "git":
+		if u.Path == "" {
+			return nil, fmt.Errorf("invalid source %q: missing checkout path", spec)
+		}
+		query := u.Query()
+		return gitSource{Checkout: u.Path, Ref: query.Get("ref"), Path: query.Get("path")}, nil
+	default:
+		return nil, fmt.Errorf("invalid source %q: unknown scheme %q", spec, u.Scheme)
+	}
+}
+
+func configuredSource() (source, error) {
+	spec := os.Getenv(sourceEnvVar)
+	if spec == "" {
+		spec = defaultSource
+	}
+	return parseSource(spec)
+}
+
+// httpSource downloads a TSV or CSV file. CSV is recognized by the response
+// content type or a .csv extension.
+type httpSource struct {
+	URL string
+	// Raw returns the file as downloaded rather than as a sheet.
+	Raw bool
+}
+
+// sheetSource exports one tab of a Google spreadsheet as TSV.
+func sheetSource(spreadsheetId string, gid string) httpSource {
+	return httpSource{URL: "https://docs.google.com/spreadsheets/d/" + url.PathEscape(spreadsheetId) + "/export?gid=" + url.QueryEscape(gid) + "&format=tsv"}
+}
+
+func (s httpSource) Fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", s.URL, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil || s.Raw {
+		return content, err
+	}
+	u, _ := url.Parse(s.URL)
+	isCsv := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") ||
+		(u != nil && strings.EqualFold(path.Ext(u.Path), ".csv"))
+	return sheetFromFile(content, isCsv)
+}
+
+// fileSource reads a local TSV or CSV file, or a directory of list files.
+type fileSource struct {
+	Path string
+	// Raw returns the file as read rather than as a sheet. Directories are
+	// not accepted.
+	Raw bool
+}
+
+func (s fileSource) Fetch(ctx context.Context) ([]byte, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() && s.Raw {
+		return nil, fmt.Errorf("%s is a directory", s.Path)
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		lists := map[string][]byte{}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".txt" {
+				continue
+			}
+			contents, err := os.ReadFile(filepath.Join(s.Path, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			lists[entry.Name()] = contents
+		}
+		return sheetFromLists(lists)
+	}
+
+	content, err := os.ReadFile(s.Path)
+	if err != nil || s.Raw {
+		return content, err
+	}
+	return sheetFromFile(content, strings.EqualFold(filepath.Ext(s.Path), ".csv"))
+}
+
+// gitSource reads a file or directory from a git checkout, either as checked
+// out or at a given ref, so lists can be kept under review in a repository.
+type gitSource struct {
+	Checkout string
+	Ref      string
+	Path     string
+	// Raw returns the file unchanged rather than as a sheet. Directories are
+	// not accepted.
+	Raw bool
+}
+
+func (s gitSource) Fetch(ctx context.Context) ([]byte, error) {
+	if s.Ref == "" {
+		return fileSource{Path: filepath.Join(s.Checkout, s.Path), Raw: s.Raw}.Fetch(ctx)
+	}
+
+	objectType, err := s.git(ctx, "cat-file", "-t", s.object(s.Path))
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(objectType)) != "tree" {
+		content, err := s.git(ctx, "show", s.object(s.Path))
+		if err != nil || s.Raw {
+			return content, err
+		}
+		return sheetFromFile(content, strings.EqualFold(path.Ext(s.Path), ".csv"))
+	}
+
+	if s.Raw {
+		return nil, fmt.Errorf("%s is a directory", s.object(s.Path))
+	}
+	names, err := s.git(ctx, "ls-tree", "--name-only", s.object(s.Path))
+	if err != nil {
+		return nil, err
+	}
+	lists := map[string][]byte{}
+	for _, name := range strings.Split(strings.TrimSpace(string(names)), "\n") {
+		if path.Ext(name) != ".txt" {
+			continue
+		}
+		contents, err := s.git(ctx, "show", s.object(path.Join(s.Path, name)))
+		if err != nil {
+			return nil, err
+		}
+		lists[name] = contents
+	}
+	return sheetFromLists(lists)
+}
+
+func (s gitSource) object(p string) string {
+	return s.Ref + ":" + p
+}
+
+func (s gitSource) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", s.Checkout}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// sheetFromFile converts the contents of a TSV or CSV file to a sheet.
+func sheetFromFile(content []byte, isCsv bool) ([]byte, error) {
+	if !isCsv {
+		return tsv.Write(tsv.Read(content)), nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return tsv.Write(records), nil
+}
+
+// sheetFromLists builds a sheet from list files named after their column, such
+// as "name@female.txt", each holding one entry per line.
+func sheetFromLists(lists map[string][]byte) ([]byte, error) {
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("no list files found")
+	}
+
+	fileNames := make([]string, 0, len(lists))
+	for fileName := range lists {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	headers := make([]string, len(fileNames))
+	columns := make([][]string, len(fileNames))
+	rows := 0
+	for i, fileName := range fileNames {
+		headers[i] = strings.TrimSuffix(fileName, ".txt")
+		columns[i] = lexicon.Lines(lists[fileName])
+		if len(columns[i]) > rows {
+			rows = len(columns[i])
+		}
+	}
+
+	records := [][]string{headers}
+	for row := 0; row < rows; row++ {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			if row < len(column) {
+				values[i] = column[row]
+			}
+		}
+		records = append(records, values)
+	}
+	return tsv.Write(records), nil
+}
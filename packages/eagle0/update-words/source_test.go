@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := map[string]source{
+		"https://example.com/words.tsv":     httpSource{URL: "https://example.com/words.tsv"},
+		"sheet:abc123#gid=7":                httpSource{URL: "https://docs.google.com/spreadsheets/d/abc123/export?gid=7&format=tsv"},
+		"sheet:abc123":                      httpSource{URL: "https://docs.google.com/spreadsheets/d/abc123/export?gid=0&format=tsv"},
+		"file:/srv/words.tsv":               fileSource{Path: "/srv/words.tsv"},
+		"/srv/words":                        fileSource{Path: "/srv/words"},
+		"git:/srv/repo?ref=main&path=lists": gitSource{Checkout: "/srv/repo", Ref: "main", Path: "lists"},
+	}
+	for spec, expected := range tests {
+		src, err := parseSource(spec)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %v", spec, err)
+			continue
+		}
+		if src != expected {
+			t.Errorf("Expected %+v for %s, got %+v", expected, spec, src)
+		}
+	}
+
+	for _, spec := range []string{"ftp://example.com/words.tsv", "sheet:", "git:?ref=main"} {
+		if _, err := parseSource(spec); err == nil {
+			t.Errorf("Expected an error for %s", spec)
+		}
+	}
+}
+
+func TestConfiguredSource_defaultsToSheet(t *testing.T) {
+	t.Setenv(sourceEnvVar, "")
+	src, err := configuredSource()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := src.(httpSource); !ok {
+		t.Errorf("Expected the default spreadsheet, got %+v", src)
+	}
+}
+
+func TestHttpSource_tsv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/tab-separated-values")
+		w.Write([]byte("name\tsurname\nAda\tJones\n"))
+	}))
+	defer server.Close()
+
+	sheet, err := httpSource{URL: server.URL + "/words"}.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(sheet) != "name\tsurname\r\nAda\tJones" {
+		t.Errorf("Unexpected sheet %q", sheet)
+	}
+}
+
+func TestHttpSource_csv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Write([]byte("name,title\nAda,\"Duchess, Dowager\"\n"))
+	}))
+	defer server.Close()
+
+	sheet, err := httpSource{URL: server.URL}.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(sheet) != "name\ttitle\r\nAda\tDuchess, Dowager" {
+		t.Errorf("Unexpected sheet %q", sheet)
+	}
+}
+
+func TestHttpSource_errorStatus(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := (httpSource{URL: server.URL}).Fetch(context.Background()); err == nil {
+		t.Errorf("Expected an error for a 404 response")
+	}
+}
+
+func TestFileSource_file(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "words.csv")
+	if err := os.WriteFile(p, []byte("name,surname\r\nAda,Jones\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet, err := fileSource{Path: p}.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(sheet) != "name\tsurname\r\nAda\tJones" {
+		t.Errorf("Unexpected sheet %q", sheet)
+	}
+}
+
+func writeLists(t *testing.T, dir string) {
+	for name, contents := range map[string]string{
+		"name@female.txt": "Ada\nAgnes\n",
+		"surname.txt":     "Jones\n\nSmith\nTaylor\n",
+		"README.md":       "not a list",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const listsSheet = "name@female\tsurname\r\nAda\tJones\r\nAgnes\tSmith\r\n\tTaylor"
+
+func TestFileSource_directory(t *testing.T) {
+	dir := t.TempDir()
+	writeLists(t, dir)
+
+	sheet, err := fileSource{Path: dir}.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(sheet) != listsSheet {
+		t.Errorf("Unexpected sheet %q", sheet)
+	}
+}
+
+func TestSheetFromLists_lineEndings(t *testing.T) {
+	sheet, err := sheetFromLists(map[string][]byte{
+		"name@female.txt": []byte("\ufeffAda\r\nAgnes\r"),
+		"surname.txt":     []byte("Jones\rSmith\n\nTaylor"),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(sheet) != listsSheet {
+		t.Errorf("Unexpected sheet %q", sheet)
+	}
+}
+
+func TestSheetFromLists_keepsQuotes(t *testing.T) {
+	sheet, err := sheetFromLists(map[string][]byte{
+		"title.txt": []byte("\"The Kid\"\n\"Mad\nDog\" x\n"),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := [][]string{{"title"}, {`"The Kid"`}, {`"Mad`}, {`Dog" x`}}
+	if records := tsv.Read(sheet); !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %q, got %q", expected, records)
+	}
+}
+
+func TestGitSource_atRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	checkout := t.TempDir()
+	lists := filepath.Join(checkout, "lists")
+	if err := os.Mkdir(lists, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeLists(t, lists)
+	src := gitSource{Checkout: checkout, Ref: "HEAD", Path: "lists"}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "lists"},
+	} {
+		if _, err := src.git(context.Background(), args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Uncommitted edits are not part of the ref.
+	if err := os.WriteFile(filepath.Join(lists, "surname.txt"), []byte("Uncommitted\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(sheet) != listsSheet {
+		t.Errorf("Unexpected sheet %q", sheet)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/release"
+	"net/url"
+	"os"
+)
+
+// templateSourceEnvVar names the environment variable holding the source of
+// the name construction template. When it is unset, the template of the
+// current release is published again with the new word lists.
+const templateSourceEnvVar = "TEMPLATE_SOURCE"
+
+// parseTemplateSource builds a source that fetches a template file unchanged.
+// It accepts the HTTP(S), file and git specifications of parseSource, but not
+// sheets or directories, which hold word lists.
+func parseTemplateSource(spec string) (source, error) {
+	if u, err := url.Parse(spec); err == nil && u.Scheme == "sheet" {
+		return nil, fmt.Errorf("invalid template source %q: sheets hold word lists", spec)
+	}
+	src, err := parseSource(spec)
+	if err != nil {
+		return nil, err
+	}
+	switch s := src.(type) {
+	case httpSource:
+		s.Raw = true
+		return s, nil
+	case fileSource:
+		s.Raw = true
+		return s, nil
+	case gitSource:
+		s.Raw = true
+		return s, nil
+	default:
+		return nil, fmt.Errorf("invalid template source %q", spec)
+	}
+}
+
+// fetchTemplate returns the template to publish: the configured template
+// source if there is one, and otherwise the template being served now.
+func fetchTemplate(ctx context.Context, store objectStore) ([]byte, error) {
+	spec := os.Getenv(templateSourceEnvVar)
+	if spec == "" {
+		return currentFile(store, release.NameConstruction)
+	}
+	src, err := parseTemplateSource(spec)
+	if err != nil {
+		return nil, err
+	}
+	return src.Fetch(ctx)
+}
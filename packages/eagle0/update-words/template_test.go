@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/release"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTemplateSource(t *testing.T) {
+	tests := map[string]source{
+		"https://example.com/nameConstruction.txt":         httpSource{URL: "https://example.com/nameConstruction.txt", Raw: true},
+		"file:/srv/nameConstruction.txt":                   fileSource{Path: "/srv/nameConstruction.txt", Raw: true},
+		"git:/srv/repo?ref=main&path=nameConstruction.txt": gitSource{Checkout: "/srv/repo", Ref: "main", Path: "nameConstruction.txt", Raw: true},
+	}
+	for spec, expected := range tests {
+		src, err := parseTemplateSource(spec)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %v", spec, err)
+			continue
+		}
+		if src != expected {
+			t.Errorf("Expected %+v for %s, got %+v", expected, spec, src)
+		}
+	}
+
+	if _, err := parseTemplateSource("sheet:abc123#gid=7"); err == nil {
+		t.Errorf("Expected an error for a sheet")
+	}
+}
+
+func TestTemplateSource_keepsContentsUnchanged(t *testing.T) {
+	// A CSV-looking template with LF endings must not be converted to a sheet.
+	const template = "$name \", \" $title\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte(template))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "nameConstruction.csv")
+	if err := os.WriteFile(path, []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, spec := range []string{server.URL + "/nameConstruction.csv", "file:" + path} {
+		src, err := parseTemplateSource(spec)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", spec, err)
+		}
+		contents, err := src.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", spec, err)
+		}
+		if string(contents) != template {
+			t.Errorf("Expected the template unchanged from %s, got %q", spec, contents)
+		}
+	}
+}
+
+func TestTemplateSource_rejectsDirectory(t *testing.T) {
+	src, err := parseTemplateSource("file:" + t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Errorf("Expected an error for a directory")
+	}
+}
+
+func TestFetchTemplate_defaultsToCurrentRelease(t *testing.T) {
+	t.Setenv(templateSourceEnvVar, "")
+	store := newMemStore(map[string]string{})
+	publishAll(t, store, defaultRetainedReleases, "name\r\nAda")
+
+	template, err := fetchTemplate(context.Background(), store)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(template) != "$name" {
+		t.Errorf("Expected the current template, got %q", template)
+	}
+}
+
+func TestUpdate_publishesTemplateWithWordLists(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	publishAll(t, store, defaultRetainedReleases, "name\r\nAda")
+
+	template := []byte(`$name " " $surname`)
+	status, result := update(store, sheets("name\tsurname\r\nAda\tJones"), template, ownership{}, allSteps, false)
+	if status != 200 {
+		t.Fatalf("Expected status 200, got %d: %+v", status, result)
+	}
+	if !result.Diff.TemplateChanged {
+		t.Errorf("Expected the template change to be reported")
+	}
+	if result.Release != release.ID([]byte("name\tsurname\r\nAda\tJones"), template) {
+		t.Errorf("Expected the release to cover both files, got %s", result.Release)
+	}
+	published, err := currentFile(store, release.NameConstruction)
+	if err != nil || string(published) != string(template) {
+		t.Errorf("Expected the new template to be current, got %q (%v)", published, err)
+	}
+}
+
+func TestUpdate_refusesTemplateWithoutLists(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	first := publishAll(t, store, defaultRetainedReleases, "name\r\nAda")[0]
+
+	status, result := update(store, sheets("name\r\nAda"), []byte(`$name " " $surname`), ownership{}, allSteps, false)
+	if status != 422 || result.Validation == nil {
+		t.Fatalf("Expected a validation failure, got %d: %+v", status, result)
+	}
+	if current := currentID(t, store); current != first {
+		t.Errorf("Expected the pointer to stay at %s, got %s", first, current)
+	}
+}
+
+func TestUpdate_refusesUnparseableTemplate(t *testing.T) {
+	store := newMemStore(map[string]string{})
+	for _, template := range []string{"[0.5 $name", "  \n"} {
+		status, result := update(store, sheets("name\r\nAda"), []byte(template), ownership{}, allSteps, false)
+		if status != 422 || result.Validation == nil {
+			t.Errorf("Expected a validation failure for %q, got %d: %+v", template, status, result)
+		}
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("Expected nothing to be written, got %d objects", len(store.objects))
+	}
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/release"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
 	"io"
-	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 type httpInfo struct {
@@ -19,13 +26,56 @@ type httpInfo struct {
 	Path   string `json:"path"`
 }
 
+// flag is a boolean request parameter. Query string parameters arrive as
+// strings, so "true", "1" and "yes" are accepted as well as JSON booleans.
+type flag bool
+
+func (f *flag) UnmarshalJSON(data []byte) error {
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		*f = flag(b)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid flag %s", data)
+	}
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "1", "yes":
+		*f = true
+	case "false", "0", "no", "":
+		*f = false
+	default:
+		return fmt.Errorf("invalid flag %q", s)
+	}
+	return nil
+}
+
 type Event struct {
-	Http httpInfo `json:"http"`
+	// Action is "publish", the default, or "rollback".
+	Action string `json:"action"`
+	// DryRun fetches, condenses and validates the sheet and reports how it
+	// differs from the published word lists, without writing anything.
+	DryRun flag     `json:"dryRun"`
+	Http   httpInfo `json:"http"`
 }
 
 var bucketName = "eagle0-config"
 
-func UpdateWords(ctx context.Context, event Event) {
+var errNotFound = errors.New("not found")
+
+// objectStore is the bucket that word lists and templates are published to.
+type objectStore interface {
+	Get(key string) ([]byte, error)
+	Put(key string, body []byte) error
+	Delete(key string) error
+}
+
+type s3Store struct {
+	client *s3.S3
+}
+
+func newS3Store() (s3Store, error) {
 	endpoint := "sfo3.digitaloceanspaces.com"
 	region := "sfo3"
 
@@ -40,74 +90,265 @@ func UpdateWords(ctx context.Context, event Event) {
 	}
 	sess, err := session.NewSession(s3Config)
 	if err != nil {
-		panic(err)
+		return s3Store{}, err
 	}
-	s3Client := s3.New(sess)
+	return s3Store{client: s3.New(sess)}, nil
+}
 
-	httpRead, err := http.Get("https://docs.google.com/spreadsheets/d/1DHEsiv4cY4gE6AX3sVH82K__mpBD1aznIYCQwQxA_F0/export?gid=0&format=tsv")
-	if err != nil {
-		fmt.Println("Error fetching file:", err)
-		return
+func (s s3Store) Get(key string) ([]byte, error) {
+	getObjInput := s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
 	}
 
-	content, err := io.ReadAll(httpRead.Body)
+	out, err := s.client.GetObject(&getObjInput)
 	if err != nil {
-		fmt.Println("Error reading response body:", err)
-		return
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, errNotFound
+		}
+		return nil, err
 	}
+	defer out.Body.Close()
 
-	toWrite := condensedTsv(string(content))
+	return io.ReadAll(out.Body)
+}
 
+func (s s3Store) Put(key string, body []byte) error {
 	putObjInput := s3.PutObjectInput{
 		Bucket: aws.String(bucketName),
-		Key:    aws.String("names.tsv"),
-		Body:   strings.NewReader(toWrite),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
 	}
 
-	out, err := s3Client.PutObject(&putObjInput)
+	out, err := s.client.PutObject(&putObjInput)
 	if err != nil {
-		fmt.Println("Error uploading file:", err)
-	} else {
-		fmt.Println("File uploaded successfully:", out)
+		return err
 	}
+	fmt.Println("File uploaded successfully:", key, out)
+	return nil
 }
 
-func condensedTsv(tsv string) string {
-	lines := strings.Split(tsv, "\r\n")
-	headers := strings.Split(lines[0], "\t")
-	namesMap := make(map[string]map[string]bool)
-	for _, h := range headers {
-		namesMap[h] = make(map[string]bool)
+func (s s3Store) Delete(key string) error {
+	deleteObjInput := s3.DeleteObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	}
+
+	if _, err := s.client.DeleteObject(&deleteObjInput); err != nil {
+		return err
+	}
+	fmt.Println("File deleted successfully:", key)
+	return nil
+}
+
+type ResponseHeaders struct {
+	ContentType string `json:"Content-Type"`
+}
+
+type Response struct {
+	Body       string          `json:"body"`
+	StatusCode string          `json:"statusCode"`
+	Headers    ResponseHeaders `json:"headers"`
+}
+
+type updateResult struct {
+	Release    string            `json:"release,omitempty"`
+	Previous   string            `json:"previous,omitempty"`
+	DryRun     bool              `json:"dryRun,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Validation *validationReport `json:"validation,omitempty"`
+	Diff       *diffReport       `json:"diff,omitempty"`
+	Conflicts  []mergeConflict   `json:"conflicts,omitempty"`
+	// Normalization lists the entries the normalization steps rewrote.
+	Normalization []normalizationChange `json:"normalization,omitempty"`
+}
+
+func jsonResponse(statusCode int, result updateResult) Response {
+	body, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("Error marshalling JSON:", err)
+		statusCode = 500
+		body = []byte(`{"error":"error marshalling JSON"}`)
+	}
+	return Response{
+		Body:       string(body),
+		StatusCode: strconv.Itoa(statusCode),
+		Headers: ResponseHeaders{
+			ContentType: "application/json",
+		},
+	}
+}
+
+func UpdateWords(ctx context.Context, event Event) Response {
+	store, err := newS3Store()
+	if err != nil {
+		fmt.Println("Error creating store:", err)
+		return jsonResponse(500, updateResult{Error: err.Error()})
+	}
+
+	switch event.Action {
+	case "", "publish":
+	case "rollback":
+		return jsonResponse(rollback(store))
+	default:
+		return jsonResponse(400, updateResult{Error: fmt.Sprintf("unknown action %q", event.Action)})
+	}
+
+	sources, owners, err := configuredSources()
+	if err != nil {
+		fmt.Println("Error configuring sources:", err)
+		return jsonResponse(500, updateResult{Error: err.Error()})
+	}
+	steps, err := configuredSteps()
+	if err != nil {
+		fmt.Println("Error configuring normalization:", err)
+		return jsonResponse(500, updateResult{Error: err.Error()})
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sheets, err := fetchAll(ctx, sources)
+	if err != nil {
+		fmt.Println("Error fetching sources:", err)
+		return jsonResponse(502, updateResult{Error: err.Error()})
+	}
+	template, err := fetchTemplate(ctx, store)
+	if err != nil {
+		fmt.Println("Error fetching template:", err)
+		return jsonResponse(502, updateResult{Error: err.Error()})
+	}
+
+	return jsonResponse(update(store, sheets, template, owners, steps, bool(event.DryRun)))
+}
+
+// fetchAll fetches every source concurrently.
+func fetchAll(ctx context.Context, sources []namedSource) ([]sourceSheet, error) {
+	sheets := make([]sourceSheet, len(sources))
+	errs := make([]error, len(sources))
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src namedSource) {
+			defer wg.Done()
+			content, err := src.Source.Fetch(ctx)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", src.Name, err)
+				return
+			}
+			sheets[i] = sourceSheet{Source: src.Name, Content: content}
+		}(i, src)
+	}
+	wg.Wait()
+	return sheets, errors.Join(errs...)
+}
+
+// update validates, normalizes, merges and condenses downloaded sheets and,
+// unless this is a dry run, publishes the result together with the template as
+// one release. It returns the status code and result to report.
+func update(store objectStore, sheets []sourceSheet, template []byte, owners ownership, steps []string, dryRun bool) (int, updateResult) {
+	report := validateSheets(sheets, string(template))
+	if !report.Ok() {
+		fmt.Println("Refusing to publish:", report)
+		return 422, updateResult{Error: "validation failed", Validation: report}
+	}
+
+	sheets, normalization := normalizeSheets(sheets, steps)
+	for _, change := range normalization {
+		fmt.Printf("Normalized %q to %q in %s (%s)\n", change.From, change.To, change.List, change.Step)
+	}
+
+	tsvs, conflicts := applyOwnership(sheets, owners)
+	for _, conflict := range conflicts {
+		fmt.Printf("Dropped %d entries for %s from %s, which is owned by %s\n", len(conflict.Dropped), conflict.List, conflict.Source, conflict.Owner)
+	}
+	toWrite := condensedTsv(tsvs...)
+
+	if report := validateMerged(toWrite, string(template)); !report.Ok() {
+		fmt.Println("Refusing to publish:", report)
+		return 422, updateResult{Error: "validation failed", Validation: report, Conflicts: conflicts, Normalization: normalization}
+	}
+
+	published, err := currentFile(store, release.NamesTsv)
+	if err != nil && !errors.Is(err, errNotFound) {
+		fmt.Println("Error fetching published word lists:", err)
+		return 500, updateResult{Error: err.Error()}
+	}
+	diff := diffTsv(string(published), toWrite)
+
+	publishedTemplate, err := currentFile(store, release.NameConstruction)
+	if err != nil && !errors.Is(err, errNotFound) {
+		fmt.Println("Error fetching published template:", err)
+		return 500, updateResult{Error: err.Error()}
+	}
+	diff.TemplateChanged = !bytes.Equal(publishedTemplate, template)
+
+	if dryRun {
+		id := release.ID([]byte(toWrite), template)
+		fmt.Println("Dry run, would publish release", id)
+		return 200, updateResult{Release: id, DryRun: true, Diff: diff, Conflicts: conflicts, Normalization: normalization}
+	}
+
+	id, previous, err := publish(store, []byte(toWrite), template, retainedReleases())
+	if err != nil {
+		fmt.Println("Error publishing release:", err)
+		return 500, updateResult{Error: err.Error(), Previous: previous, Diff: diff, Conflicts: conflicts, Normalization: normalization}
 	}
+	fmt.Println("Published release", id)
+	return 200, updateResult{Release: id, Previous: previous, Diff: diff, Conflicts: conflicts, Normalization: normalization}
+}
 
-	for _, line := range lines[1:] {
-		if len(line) == 0 {
+// condensedTsv merges the columns of one or more sheets by header, dropping
+// blank and duplicate entries. Columns are ordered from longest to shortest so
+// that every row is a prefix of the headers.
+func condensedTsv(tsvs ...string) string {
+	headers := []string{}
+	namesMap := make(map[string]map[string]bool)
+	for _, sheet := range tsvs {
+		records := tsv.Read([]byte(sheet))
+		if len(records) == 0 {
 			continue
 		}
-		values := strings.Split(line, "\t")
-		for i, h := range headers {
-			namesMap[h][strings.TrimSpace(values[i])] = true
+		sheetHeaders := records[0]
+		for _, h := range sheetHeaders {
+			if _, ok := namesMap[h]; !ok {
+				namesMap[h] = make(map[string]bool)
+				headers = append(headers, h)
+			}
+		}
+
+		for _, values := range records[1:] {
+			for i, h := range sheetHeaders {
+				if i >= len(values) {
+					break
+				}
+				// Blank cells must not count toward a column's length, or a
+				// shorter column could sort ahead of a longer one.
+				if name := strings.TrimSpace(values[i]); name != "" {
+					namesMap[h][name] = true
+				}
+			}
 		}
 	}
 
 	sort.Slice(headers, func(i, j int) bool {
-		return len(namesMap[headers[i]]) > len(namesMap[headers[j]])
+		if len(namesMap[headers[i]]) != len(namesMap[headers[j]]) {
+			return len(namesMap[headers[i]]) > len(namesMap[headers[j]])
+		}
+		return headers[i] < headers[j]
 	})
 
 	sortedNamesMap := make(map[string][]string)
 	for _, h := range headers {
 		sortedNamesMap[h] = make([]string, 0, len(namesMap[h]))
 		for name := range namesMap[h] {
-			if name == "" {
-				continue
-			}
 			sortedNamesMap[h] = append(sortedNamesMap[h], name)
 		}
 		sort.Strings(sortedNamesMap[h])
 	}
 
-	var condensedLines []string
-	condensedLines = append(condensedLines, strings.Join(headers, "\t"))
+	condensed := [][]string{headers}
 	for i := 0; ; i++ {
 		lineWords := []string{}
 		for _, h := range headers {
@@ -118,8 +359,8 @@ func condensedTsv(tsv string) string {
 		if len(lineWords) == 0 {
 			break
 		}
-		condensedLines = append(condensedLines, strings.Join(lineWords, "\t"))
+		condensed = append(condensed, lineWords)
 	}
 
-	return strings.Join(condensedLines, "\r\n")
+	return string(tsv.Write(condensed))
 }
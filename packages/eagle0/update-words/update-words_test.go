@@ -1,8 +1,242 @@
 package main
 
-import "testing"
+import (
+	"encoding/json"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/release"
+	"testing"
+)
 
 func TestUpdateWords(t *testing.T) {
 	// Test the updateWords function
 	UpdateWords(nil, Event{})
 }
+
+type memStore struct {
+	objects map[string][]byte
+}
+
+func newMemStore(objects map[string]string) *memStore {
+	s := &memStore{objects: map[string][]byte{}}
+	for key, value := range objects {
+		s.objects[key] = []byte(value)
+	}
+	return s
+}
+
+func (s *memStore) Get(key string) ([]byte, error) {
+	body, ok := s.objects[key]
+	if !ok {
+		return nil, errNotFound
+	}
+	return body, nil
+}
+
+func (s *memStore) Put(key string, body []byte) error {
+	s.objects[key] = body
+	return nil
+}
+
+func (s *memStore) Delete(key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func TestCurrentFile_fallsBackToUnversionedFile(t *testing.T) {
+	store := newMemStore(map[string]string{
+		release.NameConstruction: "$name",
+	})
+
+	template, err := currentFile(store, release.NameConstruction)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(template) != "$name" {
+		t.Errorf("Expected '$name', got '%s'", template)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	store := newMemStore(map[string]string{})
+
+	id, _, err := publish(store, []byte("name\r\nAda"), []byte("$name"), defaultRetainedReleases)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if id != release.ID([]byte("name\r\nAda"), []byte("$name")) {
+		t.Errorf("Expected the ID of the published contents, got '%s'", id)
+	}
+	if string(store.objects[release.Key(id, release.NamesTsv)]) != "name\r\nAda" {
+		t.Errorf("Expected word lists to be stored under the release")
+	}
+	if string(store.objects[release.Key(id, release.NameConstruction)]) != "$name" {
+		t.Errorf("Expected template to be stored under the release")
+	}
+	if pointer, _ := release.ParsePointer(store.objects[release.CurrentKey]); pointer != id {
+		t.Errorf("Expected current pointer to be '%s', got '%s'", id, pointer)
+	}
+}
+
+func TestPublish_keepsPreviousReleases(t *testing.T) {
+	store := newMemStore(map[string]string{
+		release.NameConstruction: "$name",
+	})
+
+	first, _, err := publish(store, []byte("name\r\nAda"), []byte("$name"), defaultRetainedReleases)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store.objects[release.NameConstruction] = []byte("-$name+")
+	template, err := currentFile(store, release.NameConstruction)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, _, err := publish(store, []byte("name\r\nBob"), template, defaultRetainedReleases)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if first == second {
+		t.Fatalf("Expected different releases for different contents")
+	}
+	if string(store.objects[release.Key(first, release.NamesTsv)]) != "name\r\nAda" {
+		t.Errorf("Expected the first release to be untouched")
+	}
+	if string(store.objects[release.Key(second, release.NameConstruction)]) != "$name" {
+		t.Errorf("Expected the template to be carried over from the current release")
+	}
+}
+
+func TestCurrentFile_followsPointer(t *testing.T) {
+	store := newMemStore(map[string]string{
+		release.NamesTsv: "name\r\nLegacy",
+	})
+	id, _, err := publish(store, []byte("name\r\nAda"), []byte("$name"), defaultRetainedReleases)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	namesTsv, err := currentFile(store, release.NamesTsv)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(namesTsv) != "name\r\nAda" {
+		t.Errorf("Expected the word lists of release %s, got '%s'", id, namesTsv)
+	}
+}
+
+func TestEvent_dryRunFlag(t *testing.T) {
+	for body, expected := range map[string]bool{
+		`{"dryRun": true}`:    true,
+		`{"dryRun": "true"}`:  true,
+		`{"dryRun": "1"}`:     true,
+		`{"dryRun": "false"}`: false,
+		`{}`:                  false,
+	} {
+		var event Event
+		if err := json.Unmarshal([]byte(body), &event); err != nil {
+			t.Errorf("Expected no error for %s, got %v", body, err)
+		}
+		if bool(event.DryRun) != expected {
+			t.Errorf("Expected dryRun %v for %s, got %v", expected, body, event.DryRun)
+		}
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(`{"dryRun": "maybe"}`), &event); err == nil {
+		t.Errorf("Expected an error for an invalid flag")
+	}
+}
+
+func TestUpdate_dryRunWritesNothing(t *testing.T) {
+	store := newMemStore(map[string]string{
+		release.NamesTsv:         "name\r\nAda",
+		release.NameConstruction: "$name",
+	})
+
+	status, result := update(store, sheets("name\r\nAda\r\nBob\r\n"), []byte("$name"), ownership{}, allSteps, true)
+	if status != 200 {
+		t.Fatalf("Expected status 200, got %d: %+v", status, result)
+	}
+	if !result.DryRun || result.Release == "" {
+		t.Errorf("Expected a dry run result naming the release, got %+v", result)
+	}
+	if len(result.Diff.Columns) != 1 || result.Diff.Columns[0].Added[0] != "Bob" {
+		t.Errorf("Expected 'Bob' to be added, got %+v", result.Diff)
+	}
+	if len(store.objects) != 2 {
+		t.Errorf("Expected nothing to be written, got %d objects", len(store.objects))
+	}
+}
+
+func TestUpdate_publishes(t *testing.T) {
+	store := newMemStore(map[string]string{
+		release.NameConstruction: "$name",
+	})
+
+	status, result := update(store, sheets("name\r\nAda\r\n"), []byte("$name"), ownership{}, allSteps, false)
+	if status != 200 {
+		t.Fatalf("Expected status 200, got %d: %+v", status, result)
+	}
+	if pointer, _ := release.ParsePointer(store.objects[release.CurrentKey]); pointer != result.Release {
+		t.Errorf("Expected current pointer to be '%s', got '%s'", result.Release, pointer)
+	}
+}
+
+func TestUpdate_refusesInvalidSheet(t *testing.T) {
+	store := newMemStore(map[string]string{
+		release.NameConstruction: "$name",
+	})
+
+	status, result := update(store, sheets("surname\r\nJones\r\n"), []byte("$name"), ownership{}, allSteps, false)
+	if status != 422 || result.Validation == nil {
+		t.Fatalf("Expected a validation failure, got %d: %+v", status, result)
+	}
+	if len(store.objects) != 1 {
+		t.Errorf("Expected nothing to be written, got %d objects", len(store.objects))
+	}
+}
+
+func TestUpdate_refusesListDroppedByOwnership(t *testing.T) {
+	store := newMemStore(map[string]string{
+		release.NameConstruction: "$name",
+	})
+
+	// Only source2 has surnames, but source1 owns them, so they are dropped.
+	owners := ownership{"surname": "source1"}
+	status, result := update(store, sheets("name\r\nAda\r\n", "surname\r\nSmith\r\n"), []byte(`$name " " $surname`), owners, allSteps, true)
+	if status != 422 || result.Validation == nil {
+		t.Fatalf("Expected a validation failure, got %d: %+v", status, result)
+	}
+	if issue := result.Validation.Issues[0]; issue.Column != "surname" {
+		t.Errorf("Expected the dropped list to be reported, got %+v", issue)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].List != "surname" {
+		t.Errorf("Expected the conflict that dropped the list, got %+v", result.Conflicts)
+	}
+}
+
+func TestCondensedTsv(t *testing.T) {
+	tsv := "name\tsurname\ttitle\r\n Bob \tJones\t\r\nAda\tJones\tDuke\r\nBob\tSmith\t\r\n"
+
+	condensed := condensedTsv(tsv)
+	expected := "name\tsurname\ttitle\r\nAda\tJones\tDuke\r\nBob\tSmith"
+	if condensed != expected {
+		t.Errorf("Expected %q, got %q", expected, condensed)
+	}
+}
+
+func TestCondensedTsv_mergesByHeader(t *testing.T) {
+	condensed := condensedTsv("surname\tname\r\nJones\tAda", "name\ttitle\r\nBob\tDuke\r\nAda\t")
+	expected := "name\tsurname\ttitle\r\nAda\tJones\tDuke\r\nBob"
+	if condensed != expected {
+		t.Errorf("Expected %q, got %q", expected, condensed)
+	}
+}
+
+func TestCondensedTsv_acceptsLfAndQuotedFields(t *testing.T) {
+	condensed := condensedTsv("\ufeffname\ttitle\nAda\t\"The\tGreat\"\nBob\t\t\n")
+	expected := "name\ttitle\r\nAda\t\"The\tGreat\"\r\nBob"
+	if condensed != expected {
+		t.Errorf("Expected %q, got %q", expected, condensed)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/loader"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/parser"
+	"github.com/nolen777/name-generator/packages/eagle0/update-words/names/tsv"
+	"strings"
+	"unicode"
+)
+
+// knownBuckets are the tags that may follow "@" in a column header.
+var knownBuckets = map[string]bool{"": true, "female": true, "male": true}
+
+type validationIssue struct {
+	// Source is the source the issue was found in, if there is more than one.
+	Source string `json:"source,omitempty"`
+	// Row is the 1-based line of the sheet, or 0 for problems not tied to a row.
+	Row     int    `json:"row,omitempty"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type validationReport struct {
+	Issues []validationIssue `json:"issues"`
+}
+
+func (report *validationReport) add(source string, row int, column string, format string, args ...any) {
+	report.Issues = append(report.Issues, validationIssue{Source: source, Row: row, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (report *validationReport) Ok() bool {
+	return len(report.Issues) == 0
+}
+
+func (report *validationReport) Error() string {
+	messages := make([]string, len(report.Issues))
+	for i, issue := range report.Issues {
+		location := ""
+		if issue.Source != "" {
+			location += fmt.Sprintf("%s ", issue.Source)
+		}
+		if issue.Row > 0 {
+			location += fmt.Sprintf("row %d ", issue.Row)
+		}
+		if issue.Column != "" {
+			location += fmt.Sprintf("column %q ", issue.Column)
+		}
+		messages[i] = location + issue.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// validateSheets checks downloaded sheets before they are merged and
+// published, and that the template that will be served with them parses.
+// Whether the template's lists are defined is left to validateMerged, since
+// merging can drop lists.
+func validateSheets(sheets []sourceSheet, template string) *validationReport {
+	report := &validationReport{}
+	for _, sheet := range sheets {
+		source := ""
+		if len(sheets) > 1 {
+			source = sheet.Source
+		}
+		validateSheet(report, source, string(sheet.Content))
+	}
+
+	if strings.TrimSpace(template) == "" {
+		report.add("", 0, "", "template is empty")
+		return report
+	}
+	if _, err := parser.ParseFrom(template); err != nil {
+		report.add("", 0, "", "template does not parse: %v", err)
+	}
+	return report
+}
+
+// validateMerged checks the word lists that will be published, after
+// normalization and ownership have been applied: they must load with the
+// template the way the names function will load them, and define every list
+// the template selects from.
+func validateMerged(namesTsv string, template string) *validationReport {
+	report := &validationReport{}
+	data, err := loader.Load([]byte(namesTsv), []byte(template))
+	if err != nil {
+		report.add("", 0, "", "merged word lists do not load: %v", err)
+		return report
+	}
+	for _, name := range data.MissingLists() {
+		report.add("", 0, name, "is used by the template but missing from the merged word lists")
+	}
+	return report
+}
+
+// validateSheet adds the problems with one sheet to the report. Rows are
+// numbered by record, which differs from the line number only when a quoted
+// entry spans lines.
+func validateSheet(report *validationReport, source string, sheet string) {
+	records := tsv.Read([]byte(sheet))
+	if len(records) == 0 {
+		report.add(source, 0, "", "is empty")
+		return
+	}
+	headers := records[0]
+	seen := map[string]bool{}
+	for _, h := range headers {
+		name, bucket, _ := strings.Cut(h, "@")
+		switch {
+		case strings.TrimSpace(h) == "":
+			report.add(source, 1, "", "has an empty column header")
+		case seen[h]:
+			report.add(source, 1, h, "is duplicated")
+		case !knownBuckets[bucket]:
+			report.add(source, 1, h, "has unknown bucket %q", bucket)
+		case name != strings.TrimSpace(name) || hasControl(name):
+			report.add(source, 1, h, "has a malformed name")
+		}
+		seen[h] = true
+	}
+
+	filled := make([]bool, len(headers))
+	for row, values := range records[1:] {
+		// Trailing empty fields aren't read, so only long rows are a problem.
+		if len(values) > len(headers) {
+			report.add(source, row+2, "", "has %d fields, expected at most %d", len(values), len(headers))
+			continue
+		}
+		for i, value := range values {
+			if hasControl(value) {
+				report.add(source, row+2, headers[i], "contains a control character in %q", value)
+			}
+			if strings.TrimSpace(value) != "" {
+				filled[i] = true
+			}
+		}
+	}
+	for i, h := range headers {
+		if !filled[i] && strings.TrimSpace(h) != "" {
+			report.add(source, 0, h, "has no entries")
+		}
+	}
+}
+
+func hasControl(s string) bool {
+	return strings.IndexFunc(s, unicode.IsControl) >= 0
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func sheets(tsvs ...string) []sourceSheet {
+	result := make([]sourceSheet, len(tsvs))
+	for i, tsv := range tsvs {
+		result[i] = sourceSheet{Source: fmt.Sprintf("source%d", i+1), Content: []byte(tsv)}
+	}
+	return result
+}
+
+func TestValidateSheet_ok(t *testing.T) {
+	tsv := "name@female\tname@male\tsurname\r\nAda\tBob\tSmith\r\nAgnes\t\tJones\r\n"
+
+	report := validateSheets(sheets(tsv), "$name \" \" $surname")
+	if !report.Ok() {
+		t.Errorf("Expected no issues, got %s", report.Error())
+	}
+}
+
+func TestValidateSheet_longRow(t *testing.T) {
+	tsv := "name\tsurname\r\nAda\tSmith\r\nBob\tJones\tExtra\r\n"
+
+	report := validateSheets(sheets(tsv), "$name")
+	if report.Ok() {
+		t.Fatalf("Expected an issue for a long row")
+	}
+	if report.Issues[0].Row != 3 || !strings.Contains(report.Issues[0].Message, "has 3 fields, expected at most 2") {
+		t.Errorf("Unexpected issue %+v", report.Issues[0])
+	}
+}
+
+func TestValidateSheet_shortRowsAndLineEndings(t *testing.T) {
+	tsv := "\ufeffname\tsurname\nAda\tSmith\nBob\n\tJones\t\n"
+
+	report := validateSheets(sheets(tsv), "$name")
+	if !report.Ok() {
+		t.Errorf("Expected no issues, got %s", report.Error())
+	}
+}
+
+func TestValidateMerged_missingTemplateColumn(t *testing.T) {
+	tsv := "given_name\tsurname\r\nAda\tSmith"
+
+	report := validateMerged(tsv, "$name \" \" #surname")
+	if report.Ok() {
+		t.Fatalf("Expected an issue for a renamed column")
+	}
+	if report.Issues[0].Column != "name" || !strings.Contains(report.Issues[0].Message, "missing from the merged word lists") {
+		t.Errorf("Unexpected issue %+v", report.Issues[0])
+	}
+}
+
+func TestValidateSheet_unknownBucket(t *testing.T) {
+	tsv := "name@femal\r\nAda\r\n"
+
+	report := validateSheets(sheets(tsv), "$name")
+	if report.Ok() || !strings.Contains(report.Error(), `unknown bucket "femal"`) {
+		t.Errorf("Expected an unknown bucket issue, got %s", report.Error())
+	}
+}
+
+func TestValidateSheet_emptyColumn(t *testing.T) {
+	tsv := "name\tsurname\r\nAda\t \r\n"
+
+	report := validateSheets(sheets(tsv), "$name")
+	if report.Ok() || report.Issues[0].Column != "surname" {
+		t.Errorf("Expected an empty column issue, got %s", report.Error())
+	}
+}
+
+func TestValidateSheet_controlCharacters(t *testing.T) {
+	tsv := "name\r\nAda\x0bLovelace\r\n"
+
+	report := validateSheets(sheets(tsv), "$name")
+	if report.Ok() || report.Issues[0].Row != 2 {
+		t.Errorf("Expected a control character issue, got %s", report.Error())
+	}
+}
+
+func TestValidateSheet_duplicateAndEmptyHeaders(t *testing.T) {
+	tsv := "name\t\tname\r\nAda\tCarl\tBob\r\n"
+
+	report := validateSheets(sheets(tsv), "$name")
+	if len(report.Issues) != 2 {
+		t.Errorf("Expected 2 issues, got %s", report.Error())
+	}
+}
+
+func TestValidateSheet_badTemplate(t *testing.T) {
+	report := validateSheets(sheets("name\r\nAda\r\n"), "[0.5 $name")
+	if report.Ok() || !strings.Contains(report.Error(), "template does not parse") {
+		t.Errorf("Expected a template issue, got %s", report.Error())
+	}
+}
+
+func TestValidateSheets_templateListsMayComeFromAnySheet(t *testing.T) {
+	report := validateSheets(sheets("name\r\nAda\r\n", "surname\r\nJones\r\n"), "$name \" \" $surname")
+	if !report.Ok() {
+		t.Errorf("Expected no issues, got %s", report.Error())
+	}
+}
+
+func TestValidateSheets_namesTheSource(t *testing.T) {
+	report := validateSheets(sheets("name\r\nAda\r\n", "surname\tplace\r\nJones\r\n"), "$name")
+	if report.Ok() {
+		t.Fatalf("Expected an issue for a short row")
+	}
+	if report.Issues[0].Source != "source2" {
+		t.Errorf("Expected the issue to name source2, got %+v", report.Issues[0])
+	}
+}